@@ -18,6 +18,7 @@ func InitFlags(name string, pkg *deb.Package, build *debgen.BuildParams) *flag.F
 	fs.StringVar(&pkg.Description, "description", "", "Description")
 	fs.BoolVar(&build.IsRmtemp, "rmtemp", false, "Remove 'temp' dirs")
 	fs.BoolVar(&build.IsVerbose, "verbose", false, "Show log messages")
+	fs.BoolVar(&build.IsReproducible, "reproducible", build.IsReproducible, "Generate reproducible output, timestamped with $SOURCE_DATE_EPOCH (or the Unix epoch)")
 
 	fs.StringVar(&build.WorkingDir, "working-dir", build.WorkingDir, "Working directory")
 	fs.StringVar(&build.TemplateDir, "template-dir", build.TemplateDir, "Template directory")
@@ -25,16 +26,34 @@ func InitFlags(name string, pkg *deb.Package, build *debgen.BuildParams) *flag.F
 	return fs
 }
 
+// InitBinaryFlags adds flags for options specific to binary packages.
+func InitBinaryFlags(fs *flag.FlagSet, build *debgen.BuildParams) {
+	fs.BoolVar(&build.IsMd5sums, "md5sums", build.IsMd5sums, "Generate an md5sums control file")
+	fs.BoolVar(&build.IsAutoConffiles, "auto-conffiles", build.IsAutoConffiles, "Mark all files under /etc as conffiles")
+	fs.BoolVar(&build.IsSysusersConf, "sysusers-conf", build.IsSysusersConf, "Add a sysusers.d configuration for the package's system users and groups")
+	fs.BoolVar(&build.IsTmpfilesConf, "tmpfiles-conf", build.IsTmpfilesConf, "Add a tmpfiles.d configuration for the home directories of the package's system users")
+	fs.StringVar(&build.ControlCompressor, "control-compression", build.ControlCompressor, "Compression for the control archive (gzip, xz, zstd or none)")
+	fs.IntVar(&build.ControlCompressionLevel, "control-compression-level", build.ControlCompressionLevel, "Compression level for the control archive (-1 for the default. 0-9 for gzip and xz, 1-22 for zstd)")
+	fs.StringVar(&build.DataCompressor, "data-compression", build.DataCompressor, "Compression for the data archive (gzip, xz, zstd or none)")
+	fs.IntVar(&build.DataCompressionLevel, "data-compression-level", build.DataCompressionLevel, "Compression level for the data archive (-1 for the default. 0-9 for gzip and xz, 1-22 for zstd)")
+}
+
 func ParseFlags(name string, pkg *deb.Package, fs *flag.FlagSet) error {
 	err := fs.Parse(os.Args[1:])
 	if err == nil {
-		err = deb.ValidatePackage(pkg)
-		if err != nil {
-			println("")
-			fmt.Fprintf(os.Stderr, "Usage of %s:\n", name)
-			fs.PrintDefaults()
-			println("")
-		}
+		err = ValidateFlags(name, pkg, fs)
+	}
+	return err
+}
+
+// ValidateFlags validates the package, printing usage information on failure.
+func ValidateFlags(name string, pkg *deb.Package, fs *flag.FlagSet) error {
+	err := deb.ValidatePackage(pkg)
+	if err != nil {
+		println("")
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", name)
+		fs.PrintDefaults()
+		println("")
 	}
 	return err
 }
@@ -5,8 +5,8 @@ import (
 	"github.com/laher/debgo-v0.2/deb"
 	"github.com/laher/debgo-v0.2/debgen"
 	"log"
-	"path/filepath"
 	"os"
+	"path/filepath"
 	"text/template"
 )
 
@@ -18,11 +18,35 @@ func main() {
 	build := debgen.NewBuildParams()
 	debgen.ApplyGoDefaults(pkg)
 	fs := cmdutils.InitFlags(name, pkg, build)
-	fs.StringVar(&pkg.Architecture, "arch", "all", "Architectures [any,386,armhf,amd64,all]")
+	fs.StringVar(&pkg.Architecture, "arch", "all", "Architectures (e.g. any, all, amd64, arm64, armhf, linux-any)")
 	var entry string
 	fs.StringVar(&entry, "entry", "", "Changelog entry data")
+	var bump string
+	fs.StringVar(&bump, "bump", "", "Bump the version of the last changelog entry (or -version): revision, nmu, backport:<release>, ppa or prerelease:<marker>")
 
-	err := cmdutils.ParseFlags(name, pkg, fs)
+	err := fs.Parse(os.Args[1:])
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	filename := filepath.Join(build.ResourcesDir, "debian", "changelog")
+	if bump != "" {
+		f, err := os.Open(filename)
+		if err == nil {
+			pkg.Version, err = debgen.LatestChangelogVersion(f)
+			f.Close()
+			if err != nil {
+				log.Fatalf("Error reading existing changelog: %v", err)
+			}
+		} else if !os.IsNotExist(err) {
+			log.Fatalf("Error reading existing changelog: %v", err)
+		}
+		pkg.Version, err = deb.BumpVersion(pkg.Version, bump)
+		if err != nil {
+			log.Fatalf("Error bumping version: %v", err)
+		}
+		log.Printf("New version: %s", pkg.Version)
+	}
+	err = cmdutils.ValidateFlags(name, pkg, fs)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
@@ -30,8 +54,7 @@ func main() {
 		log.Fatalf("Error: --entry is a required flag")
 
 	}
-	filename := filepath.Join(build.ResourcesDir, "debian", "changelog")
-	templateVars := debgen.NewTemplateData(pkg)
+	templateVars := debgen.NewTemplateDataAt(pkg, build.Timestamp())
 	templateVars.ChangelogEntry = entry
 	err = os.MkdirAll(filepath.Join(build.ResourcesDir, "debian"), 0777)
 	if err != nil {
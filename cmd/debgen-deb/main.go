@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func main() {
@@ -18,15 +19,65 @@ func main() {
 	debgen.ApplyGoDefaults(pkg)
 	fs := cmdutils.InitFlags(name, pkg, build)
 
+	cmdutils.InitBinaryFlags(fs, build)
 	var binDir string
 	var resourcesDir string
-	fs.StringVar(&binDir, "binaries", "", "directory containing binaries for each architecture. Directory names should end with the architecture")
-	fs.StringVar(&pkg.Architecture, "arch", "any", "Architectures [any,386,armhf,amd64,all]")
+	var arches string
+	var goPackages string
+	var conffiles string
+	var systemdUnits string
+	var systemUsers string
+	var systemdExecStart string
+	var maintscriptFile string
+	goBuild := debgen.NewGoBuildParams(nil)
+	fs.StringVar(&binDir, "binaries", "", "directory containing binaries for each architecture, in subdirectories named after the architecture (e.g. amd64)")
+	fs.StringVar(&pkg.Architecture, "arch", "any", "Architectures (e.g. any, all, amd64, arm64, armhf, linux-any)")
+	fs.StringVar(&arches, "arches", "", "Architectures to build for 'any' and other wildcards (e.g. amd64,arm64). Defaults to those with binaries available, or when building Go packages to i386, armhf and amd64 (or to the other Go architectures, for wildcards such as any-arm64)")
+	fs.StringVar(&goPackages, "go-packages", "", "Go packages to cross-compile for each architecture (comma-separated, e.g. ./cmd/foo). Executables are installed into "+deb.ExeDirDefault)
+	fs.BoolVar(&goBuild.IsTrimpath, "trimpath", goBuild.IsTrimpath, "Build Go packages with -trimpath")
+	fs.StringVar(&goBuild.VersionVar, "version-var", "", "Variable to inject the package version into, when building Go packages (e.g. main.Version)")
+	fs.StringVar(&goBuild.Ldflags, "ldflags", "", "Additional linker flags, when building Go packages")
+	fs.StringVar(&conffiles, "conffiles", "", "Additional conffiles, outside /etc (comma-separated, e.g. /opt/foo/foo.conf)")
 	fs.StringVar(&resourcesDir, "resources", "", "directory containing resources for this platform")
-	err := cmdutils.ParseFlags(name, pkg, fs)
+	fs.StringVar(&systemUsers, "system-users", "", "System users to create, each with a group of the same name and no home directory (comma-separated)")
+	fs.StringVar(&systemdUnits, "systemd-units", "", "systemd units to install and manage (comma-separated, e.g. foo.service). Unit files are taken from resources or templates")
+	fs.StringVar(&systemdExecStart, "systemd-exec-start", "", "Generate a systemd service named after the package, running this command (e.g. /usr/bin/foo)")
+	fs.StringVar(&maintscriptFile, "maintscript", "", "File of dpkg-maintscript-helper directives, in debhelper's debian/maintscript format (e.g. 'rm_conffile /etc/foo.conf 1.2~')")
+	err := fs.Parse(os.Args[1:])
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
+	for _, user := range strings.Split(systemUsers, ",") {
+		if strings.TrimSpace(user) != "" {
+			pkg.SystemUsers = append(pkg.SystemUsers, deb.NewSystemUser(strings.TrimSpace(user)))
+		}
+	}
+	// validated once the system users are known, before any building
+	err = cmdutils.ValidateFlags(name, pkg, fs)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	maintscripts := []*debgen.Maintscript{}
+	if maintscriptFile != "" {
+		f, err := os.Open(maintscriptFile)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		maintscripts, err = debgen.ParseMaintscript(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Error reading %s: %v", maintscriptFile, err)
+		}
+	}
+	build.Arches, err = deb.ParseArchitectures(arches)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	for _, goPackage := range strings.Split(goPackages, ",") {
+		if strings.TrimSpace(goPackage) != "" {
+			goBuild.Packages = append(goBuild.Packages, strings.TrimSpace(goPackage))
+		}
+	}
 	err = build.Init()
 	if err != nil {
 		log.Fatalf("%v", err)
@@ -35,12 +86,31 @@ func main() {
 	//log.Printf("Resources: %v", build.Resources)
 	// TODO determine this platform
 	//err = bpkg.Build(build, debgen.GenBinaryArtifact)
-	artifacts, err := deb.NewDebWriters(pkg)
+	available := []deb.Architecture{}
+	if len(goBuild.Packages) > 0 {
+		// as deb.NewDebWriters: the defaults, or the other Go architectures for wildcards such as any-arm64
+		available, err = pkg.GetArches()
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	} else if binDir != "" {
+		available, err = debgen.ArchesWithBinaries(binDir)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	artifacts, err := debgen.NewDebWriters(pkg, build, available)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
 	for arch, artifact := range artifacts {
 		dgen := debgen.NewDebGenerator(artifact, build)
+		dgen.Maintscripts = maintscripts
+		for _, conffile := range strings.Split(conffiles, ",") {
+			if strings.TrimSpace(conffile) != "" {
+				dgen.Conffiles = append(dgen.Conffiles, strings.TrimSpace(conffile))
+			}
+		}
 		err = filepath.Walk(resourcesDir, func(path string, info os.FileInfo, err2 error) error {
 			if info != nil && !info.IsDir() {
 				rel, err := filepath.Rel(resourcesDir, path)
@@ -55,18 +125,43 @@ func main() {
 			log.Fatalf("%v", err)
 		}
 
-		archBinDir := filepath.Join(binDir, string(arch))
+		if len(goBuild.Packages) > 0 {
+			err = dgen.GoBuild(goBuild)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
 
-		err = filepath.Walk(archBinDir, func(path string, info os.FileInfo, err2 error) error {
-			if info != nil && !info.IsDir() {
-				rel, err := filepath.Rel(binDir, path)
-				if err == nil {
-					dgen.OrigFiles[rel] = path
+		if binDir != "" {
+			archBinDir := filepath.Join(binDir, string(arch))
+			err = filepath.Walk(archBinDir, func(path string, info os.FileInfo, err2 error) error {
+				if info != nil && !info.IsDir() {
+					rel, err := filepath.Rel(binDir, path)
+					if err == nil {
+						dgen.OrigFiles[rel] = path
+					}
+					return err
 				}
-				return err
+				return nil
+			})
+			if err != nil {
+				log.Fatalf("%v", err)
 			}
-			return nil
-		})
+		}
+		for _, unit := range strings.Split(systemdUnits, ",") {
+			if strings.TrimSpace(unit) != "" {
+				err = dgen.AddSystemdService(debgen.NewSystemdService(strings.TrimSpace(unit), ""))
+				if err != nil {
+					log.Fatalf("%v", err)
+				}
+			}
+		}
+		if systemdExecStart != "" {
+			err = dgen.AddSystemdService(debgen.NewSystemdService(pkg.Name, systemdExecStart))
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
 		err = dgen.GenerateAllDefault()
 		if err != nil {
 			log.Fatalf("Error building for '%s': %v", arch, err)
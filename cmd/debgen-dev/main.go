@@ -15,7 +15,8 @@ func main() {
 	build := debgen.NewBuildParams()
 	debgen.ApplyGoDefaults(pkg)
 	fs := cmdutils.InitFlags(name, pkg, build)
-	fs.StringVar(&pkg.Architecture, "arch", "all", "Architectures [any,386,armhf,amd64,all]")
+	fs.StringVar(&pkg.Architecture, "arch", "all", "Architectures (e.g. any, all, amd64, arm64, armhf, linux-any)")
+	cmdutils.InitBinaryFlags(fs, build)
 	ddpkg := deb.NewDevPackage(pkg)
 
 	var sourceDir string
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/laher/debgo-v0.2/debgen"
+	"log"
+	"os"
+)
+
+func main() {
+	name := "debgen-reprotest"
+	log.SetPrefix("[" + name + "] ")
+	rp := debgen.NewReprotestParams()
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&rp.SourceDir, "source", ".", "Directory to copy into each build's working directory")
+	fs.StringVar(&rp.ArtifactsDir, "artifacts", rp.ArtifactsDir, "Directory containing the artifacts to compare, relative to the working directory")
+	fs.StringVar(&rp.TmpDir, "tmp-dir", "", "Parent directory for the builds' working directories (defaults to the system's temp directory)")
+	fs.BoolVar(&rp.IsKeepBuilds, "keep", false, "Keep the builds' working directories")
+	fs.BoolVar(&rp.IsVaryTime, "vary-time", rp.IsVaryTime, "Build in different timezones, at different times")
+	fs.BoolVar(&rp.IsVaryUmask, "vary-umask", rp.IsVaryUmask, "Build with different umasks")
+	fs.BoolVar(&rp.IsVaryWorkingDir, "vary-working-dir", rp.IsVaryWorkingDir, "Build in differently-named working directories")
+	fs.BoolVar(&rp.IsVaryLocale, "vary-locale", rp.IsVaryLocale, "Build with different locales")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", name)
+		fmt.Fprintf(os.Stderr, "  %s [flags] -- <build command> [args...]\n", name)
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(os.Args[1:])
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		log.Fatalf("Error: a build command is required")
+	}
+	diffs, err := debgen.Reprotest(rp, debgen.ReprotestCommand(fs.Arg(0), fs.Args()[1:]...))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+	if len(diffs) > 0 {
+		log.Fatalf("Not reproducible: %d difference(s)", len(diffs))
+	}
+	log.Printf("Reproducible")
+}
@@ -15,7 +15,7 @@ func main() {
 	build := debgen.NewBuildParams()
 	debgen.ApplyGoDefaults(pkg)
 	fs := cmdutils.InitFlags(name, pkg, build)
-	fs.StringVar(&pkg.Architecture, "arch", "all", "Architectures [any,386,armhf,amd64,all]")
+	fs.StringVar(&pkg.Architecture, "arch", "all", "Architectures (e.g. any, all, amd64, arm64, armhf, linux-any)")
 
 	var sourceDir string
 	var glob string
@@ -22,38 +22,246 @@ import (
 )
 
 // Architecture - processor architecture (ARM/x86/AMD64) - as named by Debian.
-// At this stage: i386, armhf, amd64 and 'all'.
-// Note that 'any' is not valid for a binary package, and resolves to [i386, armhf, amd64]
-// TODO: armel
-// (Note that armhf = ARMv7 and armel = ARMv5. In Go terms, this is is is governed by the environment variable GOARM, and 7 is the default)
+// e.g. i386, armhf, amd64, arm64 and 'all'.
+// Note that 'any' is not valid for a binary package. When building, wildcards resolve to DefaultArchitectures,
+// or to GoArchitectures for wildcards such as 'any-arm64' which match none of the defaults.
+// To build every Go architecture, resolve against GoArchitectures (see Package.ResolveArches).
+// (Note that armhf = ARMv7 and armel = ARMv5. In Go terms, this is is is governed by the environment variable GOARM)
 type Architecture string
 
 const (
-	ArchI386  Architecture = "i386"  // x86
-	ArchArmhf Architecture = "armhf" //ARMv7 TODO: armel
-	ArchAmd64 Architecture = "amd64" //For 64-bit machines
-	ArchAll   Architecture = "all"   //for binary packages
+	ArchI386     Architecture = "i386"     // x86
+	ArchArmhf    Architecture = "armhf"    //ARMv7
+	ArchArmel    Architecture = "armel"    //ARMv5
+	ArchAmd64    Architecture = "amd64"    //For 64-bit machines
+	ArchArm64    Architecture = "arm64"    //64-bit ARM
+	ArchPpc64el  Architecture = "ppc64el"  //POWER, little-endian
+	ArchS390x    Architecture = "s390x"    //IBM Z
+	ArchRiscv64  Architecture = "riscv64"  //64-bit RISC-V
+	ArchMips64el Architecture = "mips64el" //64-bit MIPS, little-endian
+	ArchMipsel   Architecture = "mipsel"   //32-bit MIPS, little-endian
+	ArchAll      Architecture = "all"      //for binary packages
 )
 
-//Binary arches only
-func resolveArches(arches string) ([]Architecture, error) {
-	if strings.HasPrefix(arches, "linux-") {
-		return resolveArches(strings.TrimPrefix(arches, "linux-"))
+// ArchTuple describes an architecture in the same terms as dpkg's tupletable: abi-libc-os-cpu
+type ArchTuple struct {
+	ABI  string
+	LibC string
+	OS   string
+	CPU  string
+}
+
+// GoTarget is the Go toolchain's equivalent of a Debian architecture
+type GoTarget struct {
+	GOOS   string
+	GOARCH string
+	GOARM  string // Only set for 32-bit ARM
+}
+
+type archTableEntry struct {
+	Arch  Architecture
+	Tuple ArchTuple
+	Go    GoTarget // Empty if Go doesn't support this architecture
+}
+
+// archTable contains the known Debian architectures, as per dpkg's tupletable & cputable.
+var archTable = []archTableEntry{
+	{ArchAmd64, ArchTuple{"base", "gnu", "linux", "amd64"}, GoTarget{"linux", "amd64", ""}},
+	{ArchArm64, ArchTuple{"base", "gnu", "linux", "arm64"}, GoTarget{"linux", "arm64", ""}},
+	{ArchArmel, ArchTuple{"eabi", "gnu", "linux", "arm"}, GoTarget{"linux", "arm", "5"}},
+	{ArchArmhf, ArchTuple{"eabihf", "gnu", "linux", "arm"}, GoTarget{"linux", "arm", "7"}},
+	{ArchI386, ArchTuple{"base", "gnu", "linux", "i386"}, GoTarget{"linux", "386", ""}},
+	{ArchMips64el, ArchTuple{"abi64", "gnu", "linux", "mips64el"}, GoTarget{"linux", "mips64le", ""}},
+	{ArchMipsel, ArchTuple{"base", "gnu", "linux", "mipsel"}, GoTarget{"linux", "mipsle", ""}},
+	{ArchPpc64el, ArchTuple{"base", "gnu", "linux", "ppc64el"}, GoTarget{"linux", "ppc64le", ""}},
+	{ArchRiscv64, ArchTuple{"base", "gnu", "linux", "riscv64"}, GoTarget{"linux", "riscv64", ""}},
+	{ArchS390x, ArchTuple{"base", "gnu", "linux", "s390x"}, GoTarget{"linux", "s390x", ""}},
+	{"loong64", ArchTuple{"base", "gnu", "linux", "loong64"}, GoTarget{"linux", "loong64", ""}},
+	{"ppc64", ArchTuple{"base", "gnu", "linux", "ppc64"}, GoTarget{"linux", "ppc64", ""}},
+	{"x32", ArchTuple{"x32", "gnu", "linux", "amd64"}, GoTarget{}},
+	{"hurd-i386", ArchTuple{"base", "gnu", "hurd", "i386"}, GoTarget{}},
+	{"hurd-amd64", ArchTuple{"base", "gnu", "hurd", "amd64"}, GoTarget{}},
+	{"kfreebsd-amd64", ArchTuple{"base", "gnu", "kfreebsd", "amd64"}, GoTarget{}},
+	{"kfreebsd-i386", ArchTuple{"base", "gnu", "kfreebsd", "i386"}, GoTarget{}},
+}
+
+// DefaultArchitectures are the architectures which 'any' (and other wildcards) resolve to, when building binary packages.
+// Wildcards which match none of these resolve to GoArchitectures instead.
+var DefaultArchitectures = []Architecture{ArchI386, ArchArmhf, ArchAmd64}
+
+// GoArchitectures are the release architectures which Go can build for.
+// To build for all of them, resolve wildcards against this list instead of DefaultArchitectures (see Package.ResolveArches).
+var GoArchitectures = []Architecture{ArchAmd64, ArchArm64, ArchArmel, ArchArmhf, ArchI386, ArchMips64el, ArchMipsel, ArchPpc64el, ArchRiscv64, ArchS390x}
+
+// KnownArchitectures returns all the concrete architectures in the architecture table.
+func KnownArchitectures() []Architecture {
+	arches := []Architecture{}
+	for _, entry := range archTable {
+		arches = append(arches, entry.Arch)
+	}
+	return arches
+}
+
+func lookupArch(arch Architecture) (*archTableEntry, error) {
+	for i := range archTable {
+		if archTable[i].Arch == arch {
+			return &archTable[i], nil
+		}
+	}
+	return nil, fmt.Errorf("Architecture %s not supported", arch)
+}
+
+// Tuple returns the abi-libc-os-cpu tuple for this architecture
+func (arch Architecture) Tuple() (ArchTuple, error) {
+	entry, err := lookupArch(arch)
+	if err != nil {
+		return ArchTuple{}, err
+	}
+	return entry.Tuple, nil
+}
+
+// IsWildcard reports whether the architecture string is a wildcard such as 'any', 'linux-any' or 'any-arm64'
+func IsWildcard(arch string) bool {
+	for _, part := range strings.Split(arch, "-") {
+		if part == "any" {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches checks whether the architecture matches a concrete architecture or a wildcard.
+// Wildcards are specified as os-cpu (e.g. 'linux-any', 'any-amd64'), libc-os-cpu or abi-libc-os-cpu, where any element may be 'any'.
+// 'all' only matches 'all'.
+func (arch Architecture) Matches(pattern string) bool {
+	if string(arch) == pattern {
+		return true
+	}
+	if arch == ArchAll || pattern == string(ArchAll) || !IsWildcard(pattern) {
+		return false
 	}
-	if strings.Contains(arches, "-") {
-		return nil, fmt.Errorf("Linux is the only OS supported. Sorry")
+	tuple, err := arch.Tuple()
+	if err != nil {
+		return false
 	}
+	parts := strings.Split(pattern, "-")
+	var wanted ArchTuple
+	switch len(parts) {
+	case 1:
+		wanted = ArchTuple{"any", "any", "any", parts[0]}
+	case 2:
+		wanted = ArchTuple{"any", "any", parts[0], parts[1]}
+	case 3:
+		wanted = ArchTuple{"any", parts[0], parts[1], parts[2]}
+	case 4:
+		wanted = ArchTuple{parts[0], parts[1], parts[2], parts[3]}
+	default:
+		return false
+	}
+	match := func(want, have string) bool {
+		return want == "any" || want == have
+	}
+	return match(wanted.ABI, tuple.ABI) && match(wanted.LibC, tuple.LibC) && match(wanted.OS, tuple.OS) && match(wanted.CPU, tuple.CPU)
+}
+
+// GoTarget returns the GOOS/GOARCH/GOARM values for building Go binaries for this architecture
+func (arch Architecture) GoTarget() (GoTarget, error) {
+	entry, err := lookupArch(arch)
+	if err != nil {
+		return GoTarget{}, err
+	}
+	if entry.Go.GOOS == "" {
+		return GoTarget{}, fmt.Errorf("Architecture %s is not supported by Go", arch)
+	}
+	return entry.Go, nil
+}
+
+// Env returns the target as environment variables, suitable for exec.Cmd.Env
+func (target GoTarget) Env() []string {
+	env := []string{"GOOS=" + target.GOOS, "GOARCH=" + target.GOARCH}
+	if target.GOARM != "" {
+		env = append(env, "GOARM="+target.GOARM)
+	}
+	return env
+}
+
+// ArchitectureFromGo finds the Debian architecture for the given GOOS/GOARCH/GOARM values.
+// An empty GOARM is treated as ARMv7 (armhf).
+func ArchitectureFromGo(goos, goarch, goarm string) (Architecture, error) {
+	if goarch == "arm" && goarm == "" {
+		goarm = "7"
+	}
+	if goarch != "arm" {
+		goarm = ""
+	}
+	for _, entry := range archTable {
+		if entry.Go.GOOS == goos && entry.Go.GOARCH == goarch && entry.Go.GOARM == goarm {
+			return entry.Arch, nil
+		}
+	}
+	return "", fmt.Errorf("No debian architecture for GOOS=%s GOARCH=%s GOARM=%s", goos, goarch, goarm)
+}
+
+// ParseArchitectures parses a comma- or space-separated list of concrete architectures (no wildcards).
+func ParseArchitectures(arches string) ([]Architecture, error) {
+	ret := []Architecture{}
+	for _, arch := range strings.FieldsFunc(arches, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if arch != string(ArchAll) {
+			if _, err := lookupArch(Architecture(arch)); err != nil {
+				return nil, err
+			}
+		}
+		ret = append(ret, Architecture(arch))
+	}
+	return ret, nil
+}
+
+// resolveArches resolves an Architecture field (a space-separated list of architectures and wildcards) to concrete architectures.
+// Wildcards are resolved against the given candidates, or against the fallback candidates if none of them match.
+func resolveArches(arches string, candidates, fallback []Architecture) ([]Architecture, error) {
+	if strings.TrimSpace(arches) == "" {
+		arches = "any"
+	}
+	ret := []Architecture{}
+	add := func(arch Architecture) {
+		for _, existing := range ret {
+			if existing == arch {
+				return
+			}
+		}
+		ret = append(ret, arch)
+	}
+	for _, pattern := range strings.Fields(arches) {
+		if pattern == string(ArchAll) {
+			add(ArchAll)
+		} else if IsWildcard(pattern) {
+			matches := matchArches(pattern, candidates)
+			if len(matches) == 0 {
+				matches = matchArches(pattern, fallback)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("Architecture wildcard %s doesn't match any supported architecture", pattern)
+			}
+			for _, arch := range matches {
+				add(arch)
+			}
+		} else {
+			_, err := lookupArch(Architecture(pattern))
+			if err != nil {
+				return nil, err
+			}
+			add(Architecture(pattern))
+		}
+	}
+	return ret, nil
+}
 
-	if arches == "any" || arches == "" {
-		return []Architecture{ArchI386, ArchArmhf, ArchAmd64}, nil
-	} else if arches == string(ArchI386) {
-		return []Architecture{ArchI386}, nil
-	} else if arches == string(ArchArmhf) {
-		return []Architecture{ArchArmhf}, nil
-	} else if arches == string(ArchAmd64) {
-		return []Architecture{ArchAmd64}, nil
-	} else if arches == string(ArchAll) {
-		return []Architecture{ArchAll}, nil
+func matchArches(pattern string, candidates []Architecture) []Architecture {
+	ret := []Architecture{}
+	for _, arch := range candidates {
+		if arch.Matches(pattern) {
+			ret = append(ret, arch)
+		}
 	}
-	return nil, fmt.Errorf("Architecture %s not supported", arches)
+	return ret
 }
@@ -0,0 +1,137 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb_test
+
+import (
+	"github.com/laher/debgo-v0.2/deb"
+	"reflect"
+	"testing"
+)
+
+func TestArchitectureMatches(t *testing.T) {
+	matches := []struct {
+		arch     deb.Architecture
+		pattern  string
+		expected bool
+	}{
+		{deb.ArchAmd64, "amd64", true},
+		{deb.ArchAmd64, "any", true},
+		{deb.ArchAmd64, "linux-any", true},
+		{deb.ArchAmd64, "any-amd64", true},
+		{deb.ArchAmd64, "gnu-linux-any", true},
+		{deb.ArchAmd64, "base-gnu-linux-any", true},
+		{deb.ArchAmd64, "any-i386", false},
+		{deb.ArchAmd64, "hurd-any", false},
+		{deb.ArchArmhf, "any-arm", true},
+		{deb.ArchArmel, "any-arm", true},
+		{deb.ArchArmhf, "eabihf-any-any-any", true},
+		{deb.ArchArmel, "eabihf-any-any-any", false},
+		{"hurd-i386", "any-i386", true},
+		{"hurd-i386", "linux-any", false},
+		{deb.ArchAll, "any", false},
+		{deb.ArchAll, "all", true},
+	}
+	for _, m := range matches {
+		if m.arch.Matches(m.pattern) != m.expected {
+			t.Errorf("%s matches %s: expected %v", m.arch, m.pattern, m.expected)
+		}
+	}
+}
+
+func TestGetArches(t *testing.T) {
+	resolutions := map[string][]deb.Architecture{
+		"amd64":           {deb.ArchAmd64},
+		"all":             {deb.ArchAll},
+		"amd64 i386":      {deb.ArchAmd64, deb.ArchI386},
+		"any-arm":         {deb.ArchArmhf},
+		"any-amd64 amd64": {deb.ArchAmd64},
+		"any":             {deb.ArchI386, deb.ArchArmhf, deb.ArchAmd64},
+		"linux-any":       {deb.ArchI386, deb.ArchArmhf, deb.ArchAmd64},
+		// no default matches, so these resolve against GoArchitectures
+		"any-arm64":          {deb.ArchArm64},
+		"any-s390x any-i386": {deb.ArchS390x, deb.ArchI386},
+	}
+	for archString, expected := range resolutions {
+		pkg := deb.NewPackage("testpkg", "1.0", "me", "desc")
+		pkg.Architecture = archString
+		arches, err := pkg.GetArches()
+		if err != nil {
+			t.Fatalf("%s: %v", archString, err)
+		}
+		if !reflect.DeepEqual(arches, expected) {
+			t.Errorf("%s: expected %v, got %v", archString, expected, arches)
+		}
+	}
+	// wider resolution is opt-in
+	goResolutions := map[string][]deb.Architecture{
+		"any-arm64": {deb.ArchArm64},
+		"any-arm":   {deb.ArchArmel, deb.ArchArmhf},
+		"any":       deb.GoArchitectures,
+	}
+	for archString, expected := range goResolutions {
+		pkg := deb.NewPackage("testpkg", "1.0", "me", "desc")
+		pkg.Architecture = archString
+		arches, err := pkg.ResolveArches(deb.GoArchitectures)
+		if err != nil {
+			t.Fatalf("%s: %v", archString, err)
+		}
+		if !reflect.DeepEqual(arches, expected) {
+			t.Errorf("%s: expected %v, got %v", archString, expected, arches)
+		}
+	}
+	for _, archString := range []string{"hurd-any", "amd65", "foo-any"} {
+		pkg := deb.NewPackage("testpkg", "1.0", "me", "desc")
+		pkg.Architecture = archString
+		_, err := pkg.GetArches()
+		if err == nil {
+			t.Errorf("%s: expected an error", archString)
+		}
+	}
+	if err := deb.ValidateArchitecture("hurd-any"); err != nil {
+		t.Errorf("hurd-any should be a valid architecture: %v", err)
+	}
+}
+
+func TestGoTarget(t *testing.T) {
+	for _, arch := range deb.GoArchitectures {
+		target, err := arch.GoTarget()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		back, err := deb.ArchitectureFromGo(target.GOOS, target.GOARCH, target.GOARM)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if back != arch {
+			t.Errorf("%s mapped to %+v, which mapped back to %s", arch, target, back)
+		}
+	}
+	target, _ := deb.ArchArmel.GoTarget()
+	if !reflect.DeepEqual(target.Env(), []string{"GOOS=linux", "GOARCH=arm", "GOARM=5"}) {
+		t.Errorf("Unexpected environment for armel: %v", target.Env())
+	}
+	arch, err := deb.ArchitectureFromGo("linux", "arm", "")
+	if err != nil || arch != deb.ArchArmhf {
+		t.Errorf("Default GOARM should map to armhf. Got %s", arch)
+	}
+	if _, err = deb.Architecture("hurd-i386").GoTarget(); err == nil {
+		t.Errorf("hurd-i386 is not supported by Go")
+	}
+	if _, err = deb.ArchitectureFromGo("windows", "amd64", ""); err == nil {
+		t.Errorf("windows is not a debian architecture")
+	}
+}
@@ -0,0 +1,131 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ConffilesDirDefault is the directory whose files are automatically marked as conffiles.
+const ConffilesDirDefault = "/etc"
+
+// ConffilePath converts a path in the data archive (e.g. 'etc/foo.conf' or './etc/foo.conf') to the absolute form used in conffiles files ('/etc/foo.conf').
+func ConffilePath(path string) string {
+	return "/" + Md5SumsPath(path)
+}
+
+// WriteConffiles writes a conffiles control file, with one absolute path per line.
+func WriteConffiles(w io.Writer, conffiles []string) error {
+	bw := bufio.NewWriter(w)
+	for _, conffile := range conffiles {
+		_, err := bw.WriteString(ConffilePath(conffile) + "\n")
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ConffileFlagRemoveOnUpgrade marks a conffile which is no longer shipped, and is removed on upgrade (dpkg 1.20.1 onwards).
+const ConffileFlagRemoveOnUpgrade = "remove-on-upgrade"
+
+// Conffile is an entry in a conffiles control file.
+type Conffile struct {
+	Path  string   // Absolute path, e.g. '/etc/foo.conf'
+	Flags []string // e.g. ConffileFlagRemoveOnUpgrade
+}
+
+// IsRemoveOnUpgrade checks whether the conffile is flagged for removal (see ConffileFlagRemoveOnUpgrade).
+func (cf *Conffile) IsRemoveOnUpgrade() bool {
+	return containsString(cf.Flags, ConffileFlagRemoveOnUpgrade)
+}
+
+// ParseConffileEntries reads a conffiles control file, including any flags preceding each path. Blank lines are ignored.
+func ParseConffileEntries(rdr io.Reader) ([]*Conffile, error) {
+	conffiles := []*Conffile{}
+	scanner := bufio.NewScanner(rdr)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		conffile := &Conffile{Flags: []string{}}
+		for !strings.HasPrefix(line, "/") {
+			parts := strings.SplitN(line, " ", 2)
+			if len(parts) < 2 {
+				return nil, fmt.Errorf("Conffile '%s' is not an absolute path", line)
+			}
+			if parts[0] != ConffileFlagRemoveOnUpgrade {
+				return nil, fmt.Errorf("Unsupported conffile flag '%s'", parts[0])
+			}
+			conffile.Flags = append(conffile.Flags, parts[0])
+			line = strings.TrimSpace(parts[1])
+		}
+		conffile.Path = line
+		conffiles = append(conffiles, conffile)
+	}
+	return conffiles, scanner.Err()
+}
+
+// ParseConffiles reads a conffiles control file, returning the paths of the conffiles shipped by the package.
+// Blank lines are ignored, as are entries flagged remove-on-upgrade (see ParseConffileEntries).
+func ParseConffiles(rdr io.Reader) ([]string, error) {
+	entries, err := ParseConffileEntries(rdr)
+	if err != nil {
+		return nil, err
+	}
+	conffiles := []string{}
+	for _, entry := range entries {
+		if !entry.IsRemoveOnUpgrade() {
+			conffiles = append(conffiles, entry.Path)
+		}
+	}
+	return conffiles, nil
+}
+
+// ValidateConffiles checks that each conffile is a file in the data archive.
+// dataFiles are the paths of the data archive's regular files, in any form accepted by ConffilePath.
+func ValidateConffiles(conffiles []string, dataFiles []string) error {
+	files := map[string]bool{}
+	for _, dataFile := range dataFiles {
+		files[ConffilePath(dataFile)] = true
+	}
+	for _, conffile := range conffiles {
+		if !files[ConffilePath(conffile)] {
+			return fmt.Errorf("Conffile '%s' is not in the data archive", conffile)
+		}
+	}
+	return nil
+}
+
+// DebGetConffiles reads the conffiles listed in a .deb's control archive.
+// Returns an empty list if the package has no conffiles.
+func DebGetConffiles(rdr io.Reader) ([]string, error) {
+	var buf bytes.Buffer
+	err := DebExtractFileL2(rdr, BinaryControlArchiveNameDefault, "conffiles", &buf)
+	if err != nil {
+		if err == ErrFileNotFound {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	return ParseConffiles(&buf)
+}
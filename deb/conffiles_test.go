@@ -0,0 +1,62 @@
+package deb_test
+
+import (
+	"bytes"
+	"github.com/laher/debgo-v0.2/deb"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConffilesRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	err := deb.WriteConffiles(&buf, []string{"/etc/a.conf", "etc/b.conf", "./opt/c.conf"})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := "/etc/a.conf\n/etc/b.conf\n/opt/c.conf\n"
+	if buf.String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, buf.String())
+	}
+	conffiles, err := deb.ParseConffiles(&buf)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !reflect.DeepEqual(conffiles, []string{"/etc/a.conf", "/etc/b.conf", "/opt/c.conf"}) {
+		t.Errorf("Unexpected conffiles %v", conffiles)
+	}
+	if _, err = deb.ParseConffiles(strings.NewReader("etc/a.conf\n")); err == nil {
+		t.Errorf("Expected an error for a relative path")
+	}
+}
+
+func TestParseConffilesRemoveOnUpgrade(t *testing.T) {
+	input := "/etc/a.conf\nremove-on-upgrade /etc/old.conf\n"
+	entries, err := deb.ParseConffileEntries(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(entries) != 2 || entries[0].IsRemoveOnUpgrade() || !entries[1].IsRemoveOnUpgrade() || entries[1].Path != "/etc/old.conf" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+	conffiles, err := deb.ParseConffiles(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !reflect.DeepEqual(conffiles, []string{"/etc/a.conf"}) {
+		t.Errorf("Conffiles flagged remove-on-upgrade aren't shipped, got %v", conffiles)
+	}
+	if _, err = deb.ParseConffiles(strings.NewReader("keep-on-upgrade /etc/a.conf\n")); err == nil {
+		t.Errorf("Expected an error for an unknown flag")
+	}
+}
+
+func TestValidateConffiles(t *testing.T) {
+	dataFiles := []string{"./etc/a.conf", "/usr/bin/a"}
+	if err := deb.ValidateConffiles([]string{"/etc/a.conf"}, dataFiles); err != nil {
+		t.Errorf("%v", err)
+	}
+	if err := deb.ValidateConffiles([]string{"/etc/b.conf"}, dataFiles); err == nil {
+		t.Errorf("Expected an error for a missing conffile")
+	}
+}
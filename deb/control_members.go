@@ -0,0 +1,187 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ControlMembers lists the optional members of a binary package's control archive, in the order they're written.
+	// The control, md5sums and conffiles members are generated separately.
+	ControlMembers = []string{"preinst", "postinst", "prerm", "postrm", "config", "templates", "triggers", "shlibs", "symbols"}
+
+	// MaintainerScriptActions lists the actions (first arguments) with which dpkg (or debconf) runs each maintainer script
+	MaintainerScriptActions = map[string][]string{
+		"preinst":  {"install", "upgrade", "abort-upgrade"},
+		"postinst": {"configure", "abort-upgrade", "abort-remove", "abort-deconfigure", "triggered", "reconfigure"},
+		"prerm":    {"remove", "upgrade", "deconfigure", "failed-upgrade"},
+		"postrm":   {"remove", "purge", "upgrade", "disappear", "failed-upgrade", "abort-install", "abort-upgrade"},
+	}
+
+	// TriggerDirectives lists the directives allowed in a triggers control file
+	TriggerDirectives = []string{"interest", "interest-await", "interest-noawait", "activate", "activate-await", "activate-noawait"}
+)
+
+// IsControlMember checks whether name is one of the optional ControlMembers
+func IsControlMember(name string) bool {
+	for _, member := range ControlMembers {
+		if member == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExecutableControlMember checks whether a control member is run by dpkg or debconf: the maintainer scripts and config.
+func IsExecutableControlMember(name string) bool {
+	if name == "config" {
+		return true
+	}
+	for _, script := range MaintainerScripts {
+		if script == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ControlMemberMode returns the permissions for a control member: 0755 for executables, otherwise 0644
+func ControlMemberMode(name string) int64 {
+	if IsExecutableControlMember(name) {
+		return 0755
+	}
+	return 0644
+}
+
+// ValidateControlMember checks the format of a control member's contents.
+func ValidateControlMember(name string, data []byte) error {
+	var err error
+	switch {
+	case !IsControlMember(name):
+		return fmt.Errorf("Unsupported control member '%s'", name)
+	case IsExecutableControlMember(name):
+		err = validateScript(data)
+	case name == "templates":
+		err = validateTemplates(data)
+	case name == "triggers":
+		err = validateTriggers(data)
+	case name == "shlibs":
+		err = validateShlibs(data)
+	case name == "symbols":
+		err = validateSymbols(data)
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid %s: %v", name, err)
+	}
+	return nil
+}
+
+func validateScript(data []byte) error {
+	if !bytes.HasPrefix(data, []byte("#!")) {
+		return fmt.Errorf("Missing interpreter line (e.g. '#!/bin/sh')")
+	}
+	return nil
+}
+
+func validateTemplates(data []byte) error {
+	templates, err := ParseDebconfTemplates(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	return ValidateDebconfTemplates(templates)
+}
+
+// eachLine calls fn for each line which isn't blank or a comment, with its line number
+func eachLine(data []byte, fn func(lineNumber int, line string) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		err := fn(lineNumber, line)
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func validateTriggers(data []byte) error {
+	return eachLine(data, func(lineNumber int, line string) error {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return fmt.Errorf("Line %d: expected '<directive> <trigger-name>'", lineNumber)
+		}
+		for _, directive := range TriggerDirectives {
+			if fields[0] == directive {
+				return nil
+			}
+		}
+		return fmt.Errorf("Line %d: unknown directive '%s'", lineNumber, fields[0])
+	})
+}
+
+func validateShlibs(data []byte) error {
+	return eachLine(data, func(lineNumber int, line string) error {
+		fields := strings.Fields(line)
+		// optional type, e.g. 'udeb:'
+		if len(fields) > 0 && strings.HasSuffix(fields[0], ":") {
+			fields = fields[1:]
+		}
+		if len(fields) < 3 {
+			return fmt.Errorf("Line %d: expected '[type:] <library-name> <soname-version> <dependencies>'", lineNumber)
+		}
+		_, err := ParseRelations(strings.Join(fields[2:], " "))
+		if err != nil {
+			return fmt.Errorf("Line %d: %v", lineNumber, err)
+		}
+		return nil
+	})
+}
+
+func validateSymbols(data []byte) error {
+	hasLibrary := false
+	return eachLine(data, func(lineNumber int, line string) error {
+		switch {
+		case strings.HasPrefix(line, " "), strings.HasPrefix(line, "\t"):
+			if !hasLibrary {
+				return fmt.Errorf("Line %d: symbol before any library", lineNumber)
+			}
+			if len(strings.Fields(line)) < 2 {
+				return fmt.Errorf("Line %d: expected '<symbol> <minimal-version> [<id-of-dependency-template>]'", lineNumber)
+			}
+		case strings.HasPrefix(line, "|"), strings.HasPrefix(line, "*"):
+			// alternative dependency templates, and meta-information fields
+			if !hasLibrary {
+				return fmt.Errorf("Line %d: '%c' line before any library", lineNumber, line[0])
+			}
+		default:
+			if len(strings.Fields(line)) < 2 {
+				return fmt.Errorf("Line %d: expected '<library-soname> <main-dependency-template>'", lineNumber)
+			}
+			hasLibrary = true
+		}
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package deb_test
+
+import (
+	"github.com/laher/debgo-v0.2/deb"
+	"testing"
+)
+
+func TestValidateControlMember(t *testing.T) {
+	valid := map[string]string{
+		"postinst":  "#!/bin/sh\nset -e\n",
+		"config":    "#!/bin/sh\n. /usr/share/debconf/confmodule\n",
+		"templates": "Template: foo/bar\nType: boolean\nDescription: Bar?\n\nTemplate: foo/baz\nType: string\nDescription: Baz?\n",
+		"triggers":  "# comment\ninterest-noawait /usr/share/foo\nactivate ldconfig\n",
+		"shlibs":    "libfoo 1 libfoo1 (>= 1.0)\nudeb: libfoo 1 libfoo1-udeb\n",
+		"symbols":   "libfoo.so.1 libfoo1 #MINVER#\n| libfoo1-alt\n* Build-Depends-Package: libfoo-dev\n foo@Base 1.0\n bar@Base 1.1 1\n",
+	}
+	for name, data := range valid {
+		if err := deb.ValidateControlMember(name, []byte(data)); err != nil {
+			t.Errorf("%s should be valid: %v", name, err)
+		}
+	}
+	invalid := map[string]string{
+		"postinst":  "set -e\n",
+		"templates": "Template: foo/bar\nDescription: Bar?\n",
+		"triggers":  "interested /usr/share/foo\n",
+		"shlibs":    "libfoo 1\n",
+		"symbols":   " foo@Base 1.0\n",
+		"md5sums":   "",
+	}
+	for name, data := range invalid {
+		if err := deb.ValidateControlMember(name, []byte(data)); err == nil {
+			t.Errorf("%s should be invalid", name)
+		}
+	}
+}
+
+func TestControlMemberMode(t *testing.T) {
+	expected := map[string]int64{"preinst": 0755, "config": 0755, "templates": 0644, "triggers": 0644, "shlibs": 0644, "symbols": 0644}
+	for name, mode := range expected {
+		if actual := deb.ControlMemberMode(name); actual != mode {
+			t.Errorf("%s: expected mode %o, got %o", name, mode, actual)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// BinaryControlFields lists the fields of a binary package's control file, in the order used by dpkg-gencontrol.
+// Any other fields are written after these, in their original order.
+var BinaryControlFields = []string{
+	"Package",
+	"Source",
+	"Version",
+	"Built-Using",
+	"Architecture",
+	"Essential",
+	"Maintainer",
+	"Installed-Size",
+	"Pre-Depends",
+	"Depends",
+	"Recommends",
+	"Suggests",
+	"Enhances",
+	"Breaks",
+	"Conflicts",
+	"Replaces",
+	"Provides",
+	"Section",
+	"Priority",
+	"Multi-Arch",
+	"Homepage",
+	"Description",
+}
+
+// WriteParagraph writes a paragraph in deb822 format.
+//
+// Multi-line values are folded: each subsequent line is indented by a space, and empty lines are written as ' .'.
+// Fields with empty values are omitted.
+func WriteParagraph(w io.Writer, para *Paragraph) error {
+	bw := bufio.NewWriter(w)
+	for _, field := range para.Fields {
+		value := strings.TrimRight(field.Value, " \t\n")
+		if value == "" {
+			continue
+		}
+		lines := strings.Split(value, "\n")
+		bw.WriteString(field.Name + ":")
+		if lines[0] != "" {
+			bw.WriteString(" " + strings.TrimSpace(lines[0]))
+		}
+		bw.WriteString("\n")
+		for _, line := range lines[1:] {
+			line = strings.TrimRight(line, " \t")
+			if line == "" {
+				line = "."
+			}
+			bw.WriteString(" " + line + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// ControlParagraph generates the control paragraph for this binary package.
+// All populated fields are included, using their official names, in the order given by BinaryControlFields.
+func (bdeb *DebWriter) ControlParagraph() *Paragraph {
+	pkg := bdeb.Package
+	para := &Paragraph{}
+	for _, name := range BinaryControlFields {
+		var value string
+		switch name {
+		case "Source":
+			if pkg.Source != pkg.Name {
+				value = pkg.Source
+			}
+		case "Architecture":
+			value = string(bdeb.Architecture)
+		case "Installed-Size":
+			value = bdeb.InstalledSizeField()
+		default:
+			value = pkg.GetField(name)
+		}
+		if value != "" {
+			para.Set(name, value)
+		}
+	}
+	for _, field := range pkg.AdditionalControlData.Fields {
+		if _, exists := para.Get(field.Name); !exists {
+			para.Set(field.Name, field.Value)
+		}
+	}
+	return para
+}
+
+// WriteControl writes the control file for this binary package.
+func (bdeb *DebWriter) WriteControl(w io.Writer) error {
+	return WriteParagraph(w, bdeb.ControlParagraph())
+}
@@ -0,0 +1,155 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb_test
+
+import (
+	"bytes"
+	"github.com/laher/debgo-v0.2/deb"
+	"os"
+	"strings"
+	"testing"
+)
+
+func ExampleDebWriter_WriteControl() {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <me@b.c>", "A synopsis\nAn extended description.\n\nA second paragraph.\n")
+	pkg.Depends = "libc6"
+	pkg.Conflicts = "oldpkg"
+	bdeb := deb.NewDebWriter(pkg, deb.ArchAmd64)
+	bdeb.WriteControl(os.Stdout)
+
+	// Output:
+	// Package: testpkg
+	// Version: 0.0.2
+	// Architecture: amd64
+	// Maintainer: me <me@b.c>
+	// Depends: libc6
+	// Conflicts: oldpkg
+	// Section: devel
+	// Priority: extra
+	// Description: A synopsis
+	//  An extended description.
+	//  .
+	//  A second paragraph.
+}
+
+func TestControlRoundTrip(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <me@b.c>", "A synopsis\nAn extended description.\n\n  verbatim")
+	pkg.PreDepends = "dpkg (>= 1.15.6)"
+	pkg.Recommends = "foo"
+	pkg.Provides = "bar"
+	pkg.Replaces = "baz"
+	pkg.Breaks = "baz (<< 1.0)"
+	pkg.SetField("X-Custom", "yes")
+	pkg.SetField("Homepage", "http://example.com")
+	var buf bytes.Buffer
+	err := deb.NewDebWriter(pkg, deb.ArchI386).WriteControl(&buf)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	paras, err := deb.ParseDeb822(&buf)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(paras) != 1 {
+		t.Fatalf("Expected a single paragraph, got %d", len(paras))
+	}
+	expected := map[string]string{
+		"Package":      "testpkg",
+		"Architecture": "i386",
+		"Pre-Depends":  "dpkg (>= 1.15.6)",
+		"Recommends":   "foo",
+		"Provides":     "bar",
+		"Replaces":     "baz",
+		"Breaks":       "baz (<< 1.0)",
+		"Homepage":     "http://example.com",
+		"X-Custom":     "yes",
+		"Description":  pkg.Description,
+	}
+	for name, value := range expected {
+		actual, _ := paras[0].Get(name)
+		if actual != value {
+			t.Errorf("%s: expected %q, got %q", name, value, actual)
+		}
+	}
+	last := paras[0].Fields[len(paras[0].Fields)-1]
+	if last.Name != "X-Custom" {
+		t.Errorf("Unknown fields should follow the standard fields: %+v", paras[0].Fields)
+	}
+}
+
+func TestControlParseAndWrite(t *testing.T) {
+	control := `Package: testpkg
+Source: testsrc
+Version: 1:0.0.2-1
+Architecture: amd64
+Essential: no
+Maintainer: me <me@b.c>
+Installed-Size: 1024
+Pre-Depends: dpkg (>= 1.15.6)
+Depends: libc6 (>= 2.14)
+Recommends: foo
+Breaks: baz (<< 1.0)
+Section: devel
+Priority: extra
+Multi-Arch: foreign
+Homepage: http://example.com
+Description: A synopsis
+ An extended description.
+ .
+   verbatim
+X-Custom: yes
+`
+	pkg, err := deb.NewDscReader(strings.NewReader(control)).Parse()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var buf bytes.Buffer
+	err = deb.NewDebWriter(pkg, deb.Architecture(pkg.Architecture)).WriteControl(&buf)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if buf.String() != control {
+		t.Errorf("Control file not reproduced. Got:\n%s", buf.String())
+	}
+}
+
+func TestControlAdditionalFieldOrder(t *testing.T) {
+	control := `Package: testpkg
+Version: 0.0.2
+Architecture: all
+Maintainer: me <me@b.c>
+Description: A synopsis
+X-Zebra: 1
+X-Apple: 2
+Original-Maintainer: you <you@b.c>
+`
+	pkg, err := deb.NewDscReader(strings.NewReader(control)).Parse()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg.SetField("x-apple", "3")
+	pkg.SetField("X-Banana", "4")
+	var buf bytes.Buffer
+	err = deb.NewDebWriter(pkg, deb.ArchAll).WriteControl(&buf)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := strings.Replace(control, "X-Apple: 2", "X-Apple: 3", 1) + "X-Banana: 4\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected control file:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,226 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ControlField is a single 'Name: Value' entry within a Paragraph.
+//
+// Multi-line values are stored without their leading continuation whitespace, and with ' .' lines converted to empty lines.
+// For example, a Description's value is the synopsis, followed by a newline and the extended description.
+// Fields such as Checksums-Sha1, whose first line is empty, have a value beginning with a newline.
+type ControlField struct {
+	Name  string
+	Value string
+}
+
+// Paragraph is an ordered set of control fields, as found in control files, .dsc & .changes files, and Packages indices.
+// Field names are matched case-insensitively, but their original case and order are preserved.
+//
+// See https://www.debian.org/doc/debian-policy/ch-controlfields.html#syntax-of-control-files
+type Paragraph struct {
+	Fields []ControlField
+}
+
+// Get returns the value of the named field, and whether it was present.
+func (para *Paragraph) Get(name string) (string, bool) {
+	i := para.index(name)
+	if i < 0 {
+		return "", false
+	}
+	return para.Fields[i].Value, true
+}
+
+// Set replaces the value of the named field, or appends the field if it's not present.
+func (para *Paragraph) Set(name, value string) {
+	i := para.index(name)
+	if i < 0 {
+		para.Fields = append(para.Fields, ControlField{Name: name, Value: value})
+	} else {
+		para.Fields[i].Value = value
+	}
+}
+
+// Delete removes the named field, if present.
+func (para *Paragraph) Delete(name string) {
+	i := para.index(name)
+	if i >= 0 {
+		para.Fields = append(para.Fields[:i], para.Fields[i+1:]...)
+	}
+}
+
+func (para *Paragraph) index(name string) int {
+	for i, field := range para.Fields {
+		if strings.EqualFold(field.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Deb822Reader reads paragraphs from a deb822-format file (a control file, .dsc, .changes, Packages index, etc).
+//
+// Comment lines (beginning with '#') are skipped.
+// If the content is clearsigned, the OpenPGP armor is skipped. The signature is not verified.
+type Deb822Reader struct {
+	reader   *bufio.Reader
+	lineNo   int
+	isSigned bool
+	isDone   bool
+}
+
+// NewDeb822Reader is a factory for Deb822Reader
+func NewDeb822Reader(rdr io.Reader) *Deb822Reader {
+	return &Deb822Reader{reader: bufio.NewReader(rdr)}
+}
+
+// ParseDeb822 reads all paragraphs.
+func ParseDeb822(rdr io.Reader) ([]*Paragraph, error) {
+	d822r := NewDeb822Reader(rdr)
+	paras := []*Paragraph{}
+	for {
+		para, err := d822r.Next()
+		if err == io.EOF {
+			return paras, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		paras = append(paras, para)
+	}
+}
+
+// readLine returns the next line without its line ending.
+func (d822r *Deb822Reader) readLine() (string, error) {
+	if d822r.isDone {
+		return "", io.EOF
+	}
+	line, err := d822r.reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	d822r.lineNo++
+	line = strings.TrimRight(line, "\r\n")
+	if d822r.lineNo == 1 && line == "-----BEGIN PGP SIGNED MESSAGE-----" {
+		d822r.isSigned = true
+		// skip armor headers (e.g. 'Hash: SHA256') up to the first blank line
+		for {
+			header, err := d822r.readLine()
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(header) == "" {
+				break
+			}
+		}
+		return d822r.readLine()
+	}
+	if d822r.isSigned {
+		if strings.HasPrefix(line, "-----BEGIN PGP SIGNATURE-----") {
+			d822r.isDone = true
+			return "", io.EOF
+		}
+		// dash-escaped text
+		line = strings.TrimPrefix(line, "- ")
+	}
+	return line, nil
+}
+
+// Next returns the next paragraph, or io.EOF when there are no more.
+func (d822r *Deb822Reader) Next() (*Paragraph, error) {
+	var para *Paragraph
+	var current *ControlField
+	for {
+		line, err := d822r.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			if para != nil {
+				// end of paragraph
+				break
+			}
+			// skip leading blank lines
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if current == nil {
+				return nil, fmt.Errorf("Line %d: continuation line without a preceding field", d822r.lineNo)
+			}
+			continuation := strings.TrimRight(line[1:], " \t")
+			if strings.TrimSpace(continuation) == "." {
+				continuation = ""
+			}
+			current.Value += "\n" + continuation
+			continue
+		}
+		if para == nil {
+			para = &Paragraph{}
+		} else if current != nil {
+			para.Fields = append(para.Fields, *current)
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("Line %d: missing ':' in field '%s'", d822r.lineNo, line)
+		}
+		name := parts[0]
+		if err := validateFieldName(name); err != nil {
+			return nil, fmt.Errorf("Line %d: %v", d822r.lineNo, err)
+		}
+		if _, exists := para.Get(name); exists {
+			return nil, fmt.Errorf("Line %d: duplicate field '%s'", d822r.lineNo, name)
+		}
+		current = &ControlField{Name: name, Value: strings.TrimSpace(parts[1])}
+	}
+	if para == nil {
+		return nil, io.EOF
+	}
+	if current != nil {
+		para.Fields = append(para.Fields, *current)
+	}
+	return para, nil
+}
+
+// validateFieldName checks for printable ASCII characters, and that the name does not start with '#' or '-'.
+func validateFieldName(name string) error {
+	if name == "" {
+		return fmt.Errorf("empty field name")
+	}
+	if name[0] == '#' || name[0] == '-' {
+		return fmt.Errorf("invalid field name '%s'", name)
+	}
+	for _, c := range name {
+		if c <= ' ' || c > '~' {
+			return fmt.Errorf("invalid field name '%s'", name)
+		}
+	}
+	return nil
+}
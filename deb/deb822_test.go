@@ -0,0 +1,128 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb_test
+
+import (
+	"github.com/laher/debgo-v0.2/deb"
+	"strings"
+	"testing"
+)
+
+const testControl = `# a comment
+Source: testpkg
+Maintainer: me <me@b.c>
+Build-Depends: debhelper (>= 9.1.0),
+ golang-go
+
+Package: testpkg
+Architecture: any
+description: A synopsis
+ The extended description.
+ .
+   A verbatim line.
+X-Custom: yes
+`
+
+const testSignedDsc = `-----BEGIN PGP SIGNED MESSAGE-----
+Hash: SHA256
+
+Format: 3.0 (quilt)
+Source: testpkg
+Version: 0.0.2
+Checksums-Sha1:
+ da39a3ee5e6b4b0d3255bfef95601890afd80709 0 testpkg_0.0.2.orig.tar.gz
+ da39a3ee5e6b4b0d3255bfef95601890afd80709 0 testpkg_0.0.2.debian.tar.gz
+
+-----BEGIN PGP SIGNATURE-----
+
+iQIzBAEBCAAdFiEE
+-----END PGP SIGNATURE-----
+`
+
+func TestParseDeb822(t *testing.T) {
+	paras, err := deb.ParseDeb822(strings.NewReader(testControl))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(paras) != 2 {
+		t.Fatalf("Expected 2 paragraphs, got %d", len(paras))
+	}
+	bd, ok := paras[0].Get("build-depends")
+	if !ok || bd != "debhelper (>= 9.1.0),\ngolang-go" {
+		t.Errorf("Unexpected Build-Depends: %q", bd)
+	}
+	desc, ok := paras[1].Get("Description")
+	if !ok || desc != "A synopsis\nThe extended description.\n\n  A verbatim line." {
+		t.Errorf("Unexpected Description: %q", desc)
+	}
+	names := []string{}
+	for _, field := range paras[1].Fields {
+		names = append(names, field.Name)
+	}
+	if strings.Join(names, ",") != "Package,Architecture,description,X-Custom" {
+		t.Errorf("Field order or case not preserved: %v", names)
+	}
+}
+
+func TestParseDeb822Signed(t *testing.T) {
+	paras, err := deb.ParseDeb822(strings.NewReader(testSignedDsc))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(paras) != 1 {
+		t.Fatalf("Expected 1 paragraph, got %d", len(paras))
+	}
+	checksums, _ := paras[0].Get("Checksums-Sha1")
+	if len(strings.Split(checksums, "\n")) != 3 || !strings.HasPrefix(checksums, "\n") {
+		t.Errorf("Unexpected Checksums-Sha1: %q", checksums)
+	}
+	if len(paras[0].Fields) != 4 {
+		t.Errorf("Unexpected fields: %+v", paras[0].Fields)
+	}
+}
+
+func TestParseDeb822Invalid(t *testing.T) {
+	invalid := []string{
+		" continuation first\n",
+		"Package: a\nno colon\n",
+		"Package: a\npackage: b\n",
+		"Package: a\n-Bad: b\n",
+	}
+	for _, in := range invalid {
+		_, err := deb.ParseDeb822(strings.NewReader(in))
+		if err == nil {
+			t.Errorf("Invalid content not detected: %q", in)
+		}
+	}
+}
+
+func TestDscReaderParse(t *testing.T) {
+	pkg, err := deb.NewDscReader(strings.NewReader(testControl)).Parse()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if pkg.Source != "testpkg" || pkg.Maintainer != "me <me@b.c>" {
+		t.Errorf("Unexpected package: %+v", pkg)
+	}
+	pkgs, err := deb.NewDscReader(strings.NewReader(testControl)).ParseAll()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(pkgs) != 2 || pkgs[1].Name != "testpkg" || pkgs[1].Architecture != "any" {
+		t.Errorf("Unexpected packages: %+v", pkgs)
+	}
+}
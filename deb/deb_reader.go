@@ -17,8 +17,9 @@
 package deb
 
 import (
-	"fmt"
 	"archive/tar"
+	"errors"
+	"fmt"
 	"github.com/laher/argo/ar"
 	"github.com/laher/debgo-v0.2/targz"
 	"io"
@@ -28,13 +29,13 @@ import (
 )
 
 type DebReader struct {
-	Reader io.Reader
-	ArReader *ar.Reader
+	Reader           io.Reader
+	ArReader         *ar.Reader
 	HasDebianVersion bool
 }
 
 func NewDebReader(rdr io.Reader) (*DebReader, error) {
-	drdr := &DebReader{Reader:rdr, HasDebianVersion: false}
+	drdr := &DebReader{Reader: rdr, HasDebianVersion: false}
 	arr, err := ar.NewReader(rdr)
 	if err != nil {
 		return nil, err
@@ -43,7 +44,30 @@ func NewDebReader(rdr io.Reader) (*DebReader, error) {
 	return drdr, err
 }
 
-//Gets next tar header for supported types
+// ErrFileNotFound is returned when a .deb doesn't contain the requested file
+var ErrFileNotFound = errors.New("File not found")
+
+// isEntryNamed checks whether a tar entry has the given name, ignoring any leading './' (as written by dpkg-deb)
+func isEntryNamed(name, wanted string) bool {
+	return strings.TrimPrefix(name, "./") == strings.TrimPrefix(wanted, "./")
+}
+
+// isTarArchive checks whether a member of the .deb is a (possibly compressed) tar archive
+func isTarArchive(name string) bool {
+	_, err := targz.CodecByFilename(name)
+	return err == nil
+}
+
+// isArchiveNamed checks whether a member of the .deb is the named archive, regardless of compression.
+// e.g. 'data.tar.xz' matches 'data.tar.gz'
+func isArchiveNamed(name, wanted string) bool {
+	if name == wanted {
+		return true
+	}
+	return isTarArchive(name) && targz.TrimExtension(name) == targz.TrimExtension(wanted)
+}
+
+// Gets next tar header for supported types
 func (drdr *DebReader) NextTar() (string, *tar.Reader, error) {
 	for {
 		hdr, err := drdr.ArReader.Next()
@@ -54,8 +78,11 @@ func (drdr *DebReader) NextTar() (string, *tar.Reader, error) {
 			drdr.HasDebianVersion = true
 			continue
 		}
-		if strings.HasSuffix(hdr.Name, ".tar.gz") {
-			tgzr, err := targz.NewReader(drdr.ArReader)
+		if isTarArchive(hdr.Name) {
+			tgzr, err := targz.NewReaderWithName(drdr.ArReader, hdr.Name)
+			if err != nil {
+				return hdr.Name, nil, err
+			}
 			return hdr.Name, tgzr.Reader, err
 		}
 		// else return error
@@ -63,7 +90,8 @@ func (drdr *DebReader) NextTar() (string, *tar.Reader, error) {
 	}
 }
 
-
+// DebGetContents lists the contents of one of the .deb's archives, e.g. 'data.tar.gz'.
+// The archive is matched regardless of its compression.
 func DebGetContents(rdr io.Reader, topLevelFilename string) ([]string, error) {
 	ret := []string{}
 	fileNotFound := true
@@ -80,11 +108,10 @@ func DebGetContents(rdr io.Reader, topLevelFilename string) ([]string, error) {
 		if err != nil {
 			return nil, err
 		}
-		if hdr.Name == topLevelFilename {
+		if isArchiveNamed(hdr.Name, topLevelFilename) {
 			fileNotFound = false
-			tgzr, err := targz.NewReader(arr)
+			tgzr, err := targz.NewReaderWithName(arr, hdr.Name)
 			if err != nil {
-				tgzr.Close()
 				return nil, err
 			}
 			for {
@@ -102,11 +129,13 @@ func DebGetContents(rdr io.Reader, topLevelFilename string) ([]string, error) {
 		}
 	}
 	if fileNotFound {
-		return nil, fmt.Errorf("File not found")
+		return nil, ErrFileNotFound
 	}
 	return ret, nil
 }
 
+// DebExtractFileL2 extracts a file from one of the .deb's archives, e.g. 'control' from 'control.tar.gz'.
+// The archive is matched regardless of its compression.
 func DebExtractFileL2(rdr io.Reader, topLevelFilename string, secondLevelFilename string, destination io.Writer) error {
 	arr, err := ar.NewReader(rdr)
 	if err != nil {
@@ -121,10 +150,9 @@ func DebExtractFileL2(rdr io.Reader, topLevelFilename string, secondLevelFilenam
 		if err != nil {
 			return err
 		}
-		if hdr.Name == topLevelFilename {
-			tgzr, err := targz.NewReader(arr)
+		if isArchiveNamed(hdr.Name, topLevelFilename) {
+			tgzr, err := targz.NewReaderWithName(arr, hdr.Name)
 			if err != nil {
-				tgzr.Close()
 				return err
 			}
 			for {
@@ -137,10 +165,10 @@ func DebExtractFileL2(rdr io.Reader, topLevelFilename string, secondLevelFilenam
 					tgzr.Close()
 					return err
 				}
-				if thdr.Name == secondLevelFilename {
+				if isEntryNamed(thdr.Name, secondLevelFilename) {
 					_, err = io.Copy(destination, tgzr)
 					tgzr.Close()
-					return nil
+					return err
 				} else {
 					//SKIP
 					log.Printf("File %s", thdr.Name)
@@ -149,10 +177,11 @@ func DebExtractFileL2(rdr io.Reader, topLevelFilename string, secondLevelFilenam
 			tgzr.Close()
 		}
 	}
-	return fmt.Errorf("File not found")
+	return ErrFileNotFound
 }
 
-// ParseDebMetadata reads an artifact's contents.
+// DebParseMetadata reads an artifact's contents.
+// The control and data archives may use any supported compression.
 func DebParseMetadata(rdr io.Reader) (*Package, error) {
 
 	arr, err := ar.NewReader(rdr)
@@ -178,13 +207,13 @@ func DebParseMetadata(rdr io.Reader) (*Package, error) {
 			return nil, err
 		}
 		//		t.Logf("File %s:\n", hdr.Name)
-		if hdr.Name == BinaryDataArchiveNameDefault {
+		if isArchiveNamed(hdr.Name, BinaryDataArchiveNameDefault) {
 			// SKIP!
 			hasDataArchive = true
-		} else if hdr.Name == BinaryControlArchiveNameDefault {
+		} else if isArchiveNamed(hdr.Name, BinaryControlArchiveNameDefault) {
 			// Find control file
 			hasControlArchive = true
-			tgzr, err := targz.NewReader(arr)
+			tgzr, err := targz.NewReaderWithName(arr, hdr.Name)
 			if err != nil {
 				return nil, err
 			}
@@ -197,7 +226,7 @@ func DebParseMetadata(rdr io.Reader) (*Package, error) {
 				if err != nil {
 					return nil, err
 				}
-				if thdr.Name == "control" {
+				if isEntryNamed(thdr.Name, "control") {
 					hasControlFile = true
 					dscr := NewDscReader(tgzr)
 					pkg, err = dscr.Parse()
@@ -228,13 +257,13 @@ func DebParseMetadata(rdr io.Reader) (*Package, error) {
 		return nil, fmt.Errorf("No debian-binary file in .deb archive")
 	}
 	if !hasDataArchive {
-		return nil, fmt.Errorf("No data.tar.gz file in .deb archive")
+		return nil, fmt.Errorf("No data archive in .deb archive")
 	}
 	if !hasControlArchive {
-		return nil, fmt.Errorf("No control.tar.gz file in .deb archive")
+		return nil, fmt.Errorf("No control archive in .deb archive")
 	}
 	if !hasControlFile {
-		return nil, fmt.Errorf("No debian/control file in control.tar.gz")
+		return nil, fmt.Errorf("No debian/control file in control archive")
 	}
 	return pkg, nil
 }
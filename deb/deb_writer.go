@@ -17,11 +17,13 @@
 package deb
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/laher/argo/ar"
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // DebWriter is an architecture-specific deb
@@ -30,24 +32,42 @@ type DebWriter struct {
 	Architecture        Architecture
 	Filename            string
 	DebianBinaryVersion string
-	ControlArchive       string
+	ControlArchive      string
 	DataArchive         string
 	MappedFiles         map[string]string
+	InstalledSize       int64     // Computed from the data archive, in KiB. See InstalledSizeField
+	ModTime             time.Time // Modification time of the ar members. Defaults to the current time. Set for reproducible builds
 }
 
 // NewDebWriters gets and returns an artifact for each architecture.
+// Wildcards resolve as for Package.GetArches.
 // Returns an error if the package's architecture is un-parseable
 func NewDebWriters(pkg *Package) (map[Architecture]*DebWriter, error) {
 	arches, err := pkg.GetArches()
 	if err != nil {
 		return nil, err
 	}
+	return newDebWriters(pkg, arches), nil
+}
+
+// NewDebWritersForArches gets and returns an artifact for each architecture.
+// Wildcards such as 'any' resolve to the matching candidates.
+// Returns an error if the package's architecture is un-parseable, or if a wildcard matches none of the candidates
+func NewDebWritersForArches(pkg *Package, candidates []Architecture) (map[Architecture]*DebWriter, error) {
+	arches, err := pkg.ResolveArches(candidates)
+	if err != nil {
+		return nil, err
+	}
+	return newDebWriters(pkg, arches), nil
+}
+
+func newDebWriters(pkg *Package, arches []Architecture) map[Architecture]*DebWriter {
 	ret := map[Architecture]*DebWriter{}
 	for _, arch := range arches {
 		archArtifact := NewDebWriter(pkg, arch)
 		ret[arch] = archArtifact
 	}
-	return ret, nil
+	return ret
 }
 
 // Factory of platform build information
@@ -117,71 +137,117 @@ func (bdeb *DebWriter) SetDefaults() {
 	bdeb.DataArchive = BinaryDataArchiveNameDefault
 }
 
-func (bdeb *DebWriter) writeBytes(aw *ar.Writer, filename string, bytes []byte) error {
+// GoTarget returns the GOOS/GOARCH/GOARM values for building Go binaries for this deb.
+// Returns an error for 'all', or for architectures which Go doesn't support.
+func (bdeb *DebWriter) GoTarget() (GoTarget, error) {
+	return bdeb.Architecture.GoTarget()
+}
+
+func (bdeb *DebWriter) writeMember(aw *ar.Writer, filename string, rdr io.Reader, size int64) error {
+	modTime := bdeb.ModTime
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
 	hdr := &ar.Header{
-		Name: filename,
-		Size: int64(len(bytes))}
+		Name:    filename,
+		ModTime: modTime,
+		Mode:    0644,
+		Size:    size}
 	if err := aw.WriteHeader(hdr); err != nil {
 		return err
 	}
-	if _, err := aw.Write(bytes); err != nil {
+	n, err := io.Copy(aw, rdr)
+	if err != nil {
 		return err
 	}
+	if n != size {
+		return fmt.Errorf("Expected %d bytes for %s, got %d", size, filename, n)
+	}
 	return nil
 }
 
-func (bdeb *DebWriter) writeFromFile(aw *ar.Writer, filename string) error {
-	finf, err := os.Stat(filename)
+// WriteAr writes the .deb's ar archive to w: the debian-binary file, followed by the control and data archives.
+// The archives' sizes must be known in advance, because they're written into the ar headers.
+func (bdeb *DebWriter) WriteAr(w io.Writer, control io.Reader, controlSize int64, data io.Reader, dataSize int64) error {
+	aw := ar.NewWriter(w)
+	debianBinary := []byte(bdeb.DebianBinaryVersion + "\n")
+	err := bdeb.writeMember(aw, "debian-binary", bytes.NewReader(debianBinary), int64(len(debianBinary)))
 	if err != nil {
-		return err
+		return fmt.Errorf("Error writing debian-binary into .ar archive: %v", err)
 	}
-	hdr, err := ar.FileInfoHeader(finf)
+	err = bdeb.writeMember(aw, bdeb.ControlArchive, control, controlSize)
 	if err != nil {
-		return err
+		return fmt.Errorf("Error writing control archive into .ar archive: %v", err)
 	}
-	if err := aw.WriteHeader(hdr); err != nil {
-		return err
+	err = bdeb.writeMember(aw, bdeb.DataArchive, data, dataSize)
+	if err != nil {
+		return fmt.Errorf("Error writing data archive into .ar archive: %v", err)
+	}
+	err = aw.Close()
+	if err != nil {
+		return fmt.Errorf("Error closing .ar archive: %v", err)
 	}
-	fi, err := os.Open(filename)
+	return nil
+}
+
+// WriteDeb generates the control and data archives, then writes the .deb to w.
+//
+// genControl and genData each write a (compressed) archive. They may change ControlArchive and DataArchive, e.g. to reflect the compression.
+// The data archive is generated first, so that the control archive can describe its contents (e.g. md5sums).
+// The control archive is buffered in memory. The data archive is spooled (see Spool), with any temporary file written to tmpDir.
+// No other files are written, so DebWriters may be used concurrently.
+func (bdeb *DebWriter) WriteDeb(w io.Writer, tmpDir string, genControl, genData func(io.Writer) error) error {
+	data := NewSpool(tmpDir, SpoolThresholdDefault)
+	defer data.Close()
+	err := genData(data)
 	if err != nil {
 		return err
 	}
-	if _, err := io.Copy(aw, fi); err != nil {
+	var control bytes.Buffer
+	err = genControl(&control)
+	if err != nil {
 		return err
 	}
-
-	err = fi.Close()
+	dataReader, err := data.Reader()
 	if err != nil {
 		return err
 	}
-	return nil
-
+	return bdeb.WriteAr(w, &control, int64(control.Len()), dataReader, data.Size())
 }
 
+// Build writes the .deb into destDir, using control and data archives previously written into tempDir.
 func (bdeb *DebWriter) Build(tempDir, destDir string) error {
 	wtr, err := os.Create(filepath.Join(destDir, bdeb.Filename))
 	if err != nil {
 		return err
 	}
-	defer wtr.Close()
-
-	aw := ar.NewWriter(wtr)
+	err = bdeb.writeArchives(wtr, tempDir)
+	if err != nil {
+		wtr.Close()
+		return err
+	}
+	return wtr.Close()
+}
 
-	err = bdeb.writeBytes(aw, "debian-binary", []byte(bdeb.DebianBinaryVersion+"\n"))
+// writeArchives writes the .deb using the control and data archives in tempDir
+func (bdeb *DebWriter) writeArchives(wtr io.Writer, tempDir string) error {
+	control, err := os.Open(filepath.Join(tempDir, bdeb.ControlArchive))
 	if err != nil {
-		return fmt.Errorf("Error writing debian-binary into .ar archive: %v", err)
+		return fmt.Errorf("Error opening control archive: %v", err)
 	}
-	err = bdeb.writeFromFile(aw, filepath.Join(tempDir, bdeb.ControlArchive))
+	defer control.Close()
+	controlInfo, err := control.Stat()
 	if err != nil {
-		return fmt.Errorf("Error writing control archive into .ar archive: %v", err)
+		return err
 	}
-	err = bdeb.writeFromFile(aw, filepath.Join(tempDir, bdeb.DataArchive))
+	data, err := os.Open(filepath.Join(tempDir, bdeb.DataArchive))
 	if err != nil {
-		return fmt.Errorf("Error writing data archive into .ar archive: %v", err)
+		return fmt.Errorf("Error opening data archive: %v", err)
 	}
-	err = aw.Close()
+	defer data.Close()
+	dataInfo, err := data.Stat()
 	if err != nil {
-		return fmt.Errorf("Error closing .ar archive: %v", err)
+		return err
 	}
-	return nil
+	return bdeb.WriteAr(wtr, control, controlInfo.Size(), data, dataInfo.Size())
 }
@@ -132,3 +132,18 @@ func createExes(exesMap map[string][]string) error {
 	}
 	return nil
 }
+
+func ExampleWriteMd5Sums() {
+	md5sums := map[string]string{
+		"./usr/share/doc/testpkg/README": "d41d8cd98f00b204e9800998ecf8427e",
+		"/usr/bin/a":                     "0cc175b9c0f1b6a831c399e269772661",
+	}
+	err := deb.WriteMd5Sums(os.Stdout, md5sums)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	// Output:
+	// 0cc175b9c0f1b6a831c399e269772661  usr/bin/a
+	// d41d8cd98f00b204e9800998ecf8427e  usr/share/doc/testpkg/README
+}
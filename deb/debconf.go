@@ -0,0 +1,246 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DebconfTypes lists the types of debconf template
+var DebconfTypes = []string{"string", "password", "boolean", "select", "multiselect", "note", "text", "error", "title"}
+
+// DebconfTemplate is a debconf question (or note, etc), as defined in a package's 'templates' control file.
+type DebconfTemplate struct {
+	Template    string // Name, conventionally '<package>/<question>'
+	Type        string // One of DebconfTypes
+	Default     string
+	Choices     string // Comma-separated choices, for select and multiselect. Literal commas are escaped as '\,'
+	Description string // Short description, then any extended description on subsequent lines
+
+	LocalizedChoices      map[string]string // Translated Choices by language, e.g. 'de' or 'de.UTF-8'
+	LocalizedDescriptions map[string]string // Translated Descriptions by language, e.g. 'de' or 'de.UTF-8'
+	AdditionalFields      Paragraph         // Any other fields, in order (e.g. 'Default-de')
+}
+
+// NewDebconfTemplate is a factory for DebconfTemplate
+func NewDebconfTemplate(name, templateType, description string) *DebconfTemplate {
+	return &DebconfTemplate{Template: name, Type: templateType, Description: description,
+		LocalizedChoices: map[string]string{}, LocalizedDescriptions: map[string]string{}}
+}
+
+// NewDebconfTemplateFromParagraph is a factory for DebconfTemplate, populated from a parsed paragraph
+func NewDebconfTemplateFromParagraph(para *Paragraph) *DebconfTemplate {
+	tpl := NewDebconfTemplate("", "", "")
+	for _, field := range para.Fields {
+		name := strings.ToLower(field.Name)
+		switch {
+		case name == "template":
+			tpl.Template = field.Value
+		case name == "type":
+			tpl.Type = field.Value
+		case name == "default":
+			tpl.Default = field.Value
+		case name == "choices":
+			tpl.Choices = field.Value
+		case name == "description":
+			tpl.Description = field.Value
+		case strings.HasPrefix(name, "choices-"):
+			tpl.LocalizedChoices[field.Name[len("choices-"):]] = field.Value
+		case strings.HasPrefix(name, "description-"):
+			tpl.LocalizedDescriptions[field.Name[len("description-"):]] = field.Value
+		default:
+			tpl.AdditionalFields.Set(field.Name, field.Value)
+		}
+	}
+	return tpl
+}
+
+// Paragraph converts the template to a deb822 paragraph.
+// Localized fields follow their untranslated equivalent, ordered by language.
+func (tpl *DebconfTemplate) Paragraph() *Paragraph {
+	para := &Paragraph{}
+	para.Set("Template", tpl.Template)
+	para.Set("Type", tpl.Type)
+	para.Set("Default", tpl.Default)
+	para.Set("Choices", tpl.Choices)
+	for _, lang := range sortedKeys(tpl.LocalizedChoices) {
+		para.Set("Choices-"+lang, tpl.LocalizedChoices[lang])
+	}
+	para.Set("Description", tpl.Description)
+	for _, lang := range sortedKeys(tpl.LocalizedDescriptions) {
+		para.Set("Description-"+lang, tpl.LocalizedDescriptions[lang])
+	}
+	para.Fields = append(para.Fields, tpl.AdditionalFields.Fields...)
+	return para
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SplitDebconfChoices splits a Choices field into its choices. Escaped commas ('\,') are unescaped.
+func SplitDebconfChoices(choices string) []string {
+	ret := []string{}
+	current := ""
+	for i := 0; i < len(choices); i++ {
+		if choices[i] == '\\' && i+1 < len(choices) && choices[i+1] == ',' {
+			current += ","
+			i++
+		} else if choices[i] == ',' {
+			ret = append(ret, strings.TrimSpace(current))
+			current = ""
+		} else {
+			current += string(choices[i])
+		}
+	}
+	if strings.TrimSpace(current) != "" || len(ret) > 0 {
+		ret = append(ret, strings.TrimSpace(current))
+	}
+	return ret
+}
+
+// Validate checks the template's name, type and description, and that its choices and default are consistent with its type.
+func (tpl *DebconfTemplate) Validate() error {
+	if tpl.Template == "" || strings.ContainsAny(tpl.Template, " \t\n") {
+		return fmt.Errorf("Invalid template name '%s'", tpl.Template)
+	}
+	isValidType := false
+	for _, templateType := range DebconfTypes {
+		if tpl.Type == templateType {
+			isValidType = true
+		}
+	}
+	if !isValidType {
+		return fmt.Errorf("Template %s: invalid type '%s'", tpl.Template, tpl.Type)
+	}
+	if strings.TrimSpace(strings.SplitN(tpl.Description, "\n", 2)[0]) == "" {
+		return fmt.Errorf("Template %s: missing short description", tpl.Template)
+	}
+	isSelect := tpl.Type == "select" || tpl.Type == "multiselect"
+	if isSelect && tpl.Choices == "" {
+		return fmt.Errorf("Template %s: %s requires Choices", tpl.Template, tpl.Type)
+	}
+	if !isSelect && (tpl.Choices != "" || len(tpl.LocalizedChoices) > 0) {
+		return fmt.Errorf("Template %s: Choices are only allowed for select and multiselect", tpl.Template)
+	}
+	choices := SplitDebconfChoices(tpl.Choices)
+	for lang, localized := range tpl.LocalizedChoices {
+		if len(SplitDebconfChoices(localized)) != len(choices) {
+			return fmt.Errorf("Template %s: Choices-%s has %d choices, expected %d", tpl.Template, lang, len(SplitDebconfChoices(localized)), len(choices))
+		}
+	}
+	switch {
+	case tpl.Type == "boolean":
+		if tpl.Default != "" && tpl.Default != "true" && tpl.Default != "false" {
+			return fmt.Errorf("Template %s: boolean Default must be 'true' or 'false'", tpl.Template)
+		}
+	case strings.Contains(tpl.Choices, "${"):
+		// choices are substituted at runtime, so the default can't be checked
+	case tpl.Type == "select":
+		if tpl.Default != "" && !containsString(choices, tpl.Default) {
+			return fmt.Errorf("Template %s: Default '%s' is not one of the Choices", tpl.Template, tpl.Default)
+		}
+	case tpl.Type == "multiselect":
+		for _, value := range SplitDebconfChoices(tpl.Default) {
+			if !containsString(choices, value) {
+				return fmt.Errorf("Template %s: Default '%s' is not one of the Choices", tpl.Template, value)
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDebconfTemplates validates each template, and checks that template names are unique
+func ValidateDebconfTemplates(templates []*DebconfTemplate) error {
+	if len(templates) == 0 {
+		return fmt.Errorf("No templates defined")
+	}
+	names := map[string]bool{}
+	for _, tpl := range templates {
+		err := tpl.Validate()
+		if err != nil {
+			return err
+		}
+		if names[tpl.Template] {
+			return fmt.Errorf("Duplicate template '%s'", tpl.Template)
+		}
+		names[tpl.Template] = true
+	}
+	return nil
+}
+
+// ParseDebconfTemplates parses a 'templates' file. Templates are not validated (see ValidateDebconfTemplates).
+func ParseDebconfTemplates(rdr io.Reader) ([]*DebconfTemplate, error) {
+	paras, err := ParseDeb822(rdr)
+	if err != nil {
+		return nil, err
+	}
+	templates := []*DebconfTemplate{}
+	for _, para := range paras {
+		templates = append(templates, NewDebconfTemplateFromParagraph(para))
+	}
+	return templates, nil
+}
+
+// WriteDebconfTemplates writes a 'templates' file, with paragraphs separated by blank lines
+func WriteDebconfTemplates(w io.Writer, templates []*DebconfTemplate) error {
+	for i, tpl := range templates {
+		if i > 0 {
+			_, err := w.Write([]byte("\n"))
+			if err != nil {
+				return err
+			}
+		}
+		err := WriteParagraph(w, tpl.Paragraph())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DebGetDebconfTemplates reads the debconf templates from a .deb's control archive.
+// Returns an empty list if the package has no templates.
+func DebGetDebconfTemplates(rdr io.Reader) ([]*DebconfTemplate, error) {
+	var buf bytes.Buffer
+	err := DebExtractFileL2(rdr, BinaryControlArchiveNameDefault, "templates", &buf)
+	if err != nil {
+		if err == ErrFileNotFound {
+			return []*DebconfTemplate{}, nil
+		}
+		return nil, err
+	}
+	return ParseDebconfTemplates(&buf)
+}
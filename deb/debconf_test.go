@@ -0,0 +1,85 @@
+package deb_test
+
+import (
+	"bytes"
+	"github.com/laher/debgo-v0.2/deb"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDebconfTemplatesRoundTrip(t *testing.T) {
+	input := `Template: foo/colour
+Type: select
+Default: red
+Choices: red, green, blue\, ish
+Choices-de.UTF-8: rot, grün, blau\, etwa
+Description: Favourite colour?
+ Choose a colour.
+ .
+ Any colour.
+Description-de.UTF-8: Lieblingsfarbe?
+ Wählen Sie eine Farbe.
+
+Template: foo/enable
+Type: boolean
+Default: true
+Description: Enable foo?
+`
+	templates, err := deb.ParseDebconfTemplates(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err = deb.ValidateDebconfTemplates(templates); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(templates) != 2 {
+		t.Fatalf("Expected 2 templates, got %d", len(templates))
+	}
+	expectedChoices := []string{"red", "green", "blue, ish"}
+	if choices := deb.SplitDebconfChoices(templates[0].Choices); !reflect.DeepEqual(choices, expectedChoices) {
+		t.Errorf("Expected choices %v, got %v", expectedChoices, choices)
+	}
+	if templates[0].LocalizedDescriptions["de.UTF-8"] != "Lieblingsfarbe?\nWählen Sie eine Farbe." {
+		t.Errorf("Unexpected localized description '%s'", templates[0].LocalizedDescriptions["de.UTF-8"])
+	}
+	var buf bytes.Buffer
+	if err = deb.WriteDebconfTemplates(&buf, templates); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if buf.String() != input {
+		t.Errorf("Expected:\n%s\ngot:\n%s", input, buf.String())
+	}
+}
+
+func TestDebconfTemplateValidate(t *testing.T) {
+	invalid := []*deb.DebconfTemplate{
+		deb.NewDebconfTemplate("", "string", "Name?"),
+		deb.NewDebconfTemplate("foo/name", "strong", "Name?"),
+		deb.NewDebconfTemplate("foo/name", "string", ""),
+		deb.NewDebconfTemplate("foo/colour", "select", "Colour?"),
+		{Template: "foo/name", Type: "string", Choices: "a, b", Description: "Name?"},
+		{Template: "foo/enable", Type: "boolean", Default: "yes", Description: "Enable?"},
+		{Template: "foo/colour", Type: "select", Choices: "red, green", Default: "blue", Description: "Colour?"},
+		{Template: "foo/colours", Type: "multiselect", Choices: "red, green", Default: "red, blue", Description: "Colours?"},
+		{Template: "foo/colour", Type: "select", Choices: "red, green", LocalizedChoices: map[string]string{"de": "rot"}, Description: "Colour?"},
+	}
+	for _, tpl := range invalid {
+		if err := tpl.Validate(); err == nil {
+			t.Errorf("Template should be invalid: %+v", tpl)
+		}
+	}
+	valid := []*deb.DebconfTemplate{
+		{Template: "foo/colours", Type: "multiselect", Choices: "red, green", Default: "red, green", Description: "Colours?"},
+		{Template: "foo/iface", Type: "select", Choices: "${ifaces}", Default: "eth0", Description: "Interface?"},
+	}
+	for _, tpl := range valid {
+		if err := tpl.Validate(); err != nil {
+			t.Errorf("Template should be valid: %v", err)
+		}
+	}
+	duplicates := []*deb.DebconfTemplate{deb.NewDebconfTemplate("foo/note", "note", "Note"), deb.NewDebconfTemplate("foo/note", "note", "Note")}
+	if err := deb.ValidateDebconfTemplates(duplicates); err == nil {
+		t.Errorf("Duplicate templates should be invalid")
+	}
+}
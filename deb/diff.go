@@ -0,0 +1,283 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"github.com/laher/argo/ar"
+	"github.com/laher/debgo-v0.2/targz"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Difference describes one way in which two artifacts differ.
+type Difference struct {
+	Path  string // Location of the difference, with nested archive members separated by ':', e.g. 'foo_1.0_amd64.deb:data.tar.gz:./usr/bin/foo'
+	Field string // What differs, e.g. 'mode', 'mtime', 'md5', 'presence', 'position' or 'field Version'
+	A     string // Value in the first artifact
+	B     string // Value in the second artifact
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %s differs: '%s' vs '%s'", d.Path, d.Field, d.A, d.B)
+}
+
+// archiveEntry summarises a file or archive member for comparison
+type archiveEntry struct {
+	name     string
+	fields   [][2]string // metadata, as ordered name/value pairs
+	md5      string
+	para     *Paragraph      // parsed contents of control files
+	entries  []*archiveEntry // contents of archives
+	isParsed bool            // whether para or entries describe the contents
+}
+
+// DiffDebs compares two .deb files structurally: the ar members, the entries of the control and data archives (metadata and content hashes), and the control file's fields.
+func DiffDebs(a, b io.Reader) ([]Difference, error) {
+	return diffFiles("", true, a, b)
+}
+
+// DiffFiles compares two artifacts named filename, structurally if the format is understood:
+// .deb files (see DiffDebs), (compressed) tar archives such as .orig.tar.gz, and .dsc files.
+// Other files are compared by content.
+// Paths in the returned differences begin with filename.
+func DiffFiles(filename string, a, b io.Reader) ([]Difference, error) {
+	return diffFiles(filename, strings.HasSuffix(filename, ".deb"), a, b)
+}
+
+func diffFiles(filename string, isDeb bool, a, b io.Reader) ([]Difference, error) {
+	ea, err := summariseFile(filename, isDeb, a)
+	if err != nil {
+		return nil, err
+	}
+	eb, err := summariseFile(filename, isDeb, b)
+	if err != nil {
+		return nil, err
+	}
+	return diffEntry(filename, ea, eb), nil
+}
+
+func summariseFile(filename string, isDeb bool, rdr io.Reader) (*archiveEntry, error) {
+	entry := &archiveEntry{name: filename}
+	h := md5.New()
+	tee := io.TeeReader(rdr, h)
+	var err error
+	switch {
+	case isDeb:
+		entry.entries, err = summariseAr(tee)
+		entry.isParsed = true
+	case isTarArchive(filename):
+		entry.entries, err = summariseTarArchive(tee, filename, false)
+		entry.isParsed = true
+	case strings.HasSuffix(filename, ".dsc"):
+		entry.para, entry.isParsed = parseParagraph(tee)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %v", filename, err)
+	}
+	_, err = io.Copy(ioutil.Discard, tee)
+	if err != nil {
+		return nil, err
+	}
+	entry.md5 = fmt.Sprintf("%x", h.Sum(nil))
+	return entry, nil
+}
+
+func summariseAr(rdr io.Reader) ([]*archiveEntry, error) {
+	arr, err := ar.NewReader(rdr)
+	if err != nil {
+		return nil, err
+	}
+	entries := []*archiveEntry{}
+	for {
+		hdr, err := arr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := &archiveEntry{name: hdr.Name, fields: [][2]string{
+			{"mode", fmt.Sprintf("%o", hdr.Mode)},
+			{"uid", fmt.Sprintf("%d", hdr.Uid)},
+			{"gid", fmt.Sprintf("%d", hdr.Gid)},
+			{"mtime", fmt.Sprintf("%d", hdr.ModTime.Unix())},
+			{"size", fmt.Sprintf("%d", hdr.Size)},
+		}}
+		h := md5.New()
+		tee := io.TeeReader(arr, h)
+		if isTarArchive(hdr.Name) {
+			entry.entries, err = summariseTarArchive(tee, hdr.Name, isArchiveNamed(hdr.Name, BinaryControlArchiveNameDefault))
+			if err != nil {
+				return nil, fmt.Errorf("Error reading %s: %v", hdr.Name, err)
+			}
+			entry.isParsed = true
+		}
+		_, err = io.Copy(ioutil.Discard, tee)
+		if err != nil {
+			return nil, err
+		}
+		entry.md5 = fmt.Sprintf("%x", h.Sum(nil))
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func summariseTarArchive(rdr io.Reader, filename string, isControlArchive bool) ([]*archiveEntry, error) {
+	tgzr, err := targz.NewReaderWithName(rdr, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer tgzr.Close()
+	entries := []*archiveEntry{}
+	for {
+		hdr, err := tgzr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := &archiveEntry{name: hdr.Name, fields: [][2]string{
+			{"type", string(hdr.Typeflag)},
+			{"mode", fmt.Sprintf("%o", hdr.Mode)},
+			{"uid", fmt.Sprintf("%d", hdr.Uid)},
+			{"gid", fmt.Sprintf("%d", hdr.Gid)},
+			{"uname", hdr.Uname},
+			{"gname", hdr.Gname},
+			{"mtime", fmt.Sprintf("%d", hdr.ModTime.Unix())},
+			{"linkname", hdr.Linkname},
+			{"size", fmt.Sprintf("%d", hdr.Size)},
+		}}
+		if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+			h := md5.New()
+			tee := io.TeeReader(tgzr, h)
+			if isControlArchive && isEntryNamed(hdr.Name, "control") {
+				entry.para, entry.isParsed = parseParagraph(tee)
+			}
+			_, err = io.Copy(ioutil.Discard, tee)
+			if err != nil {
+				return nil, err
+			}
+			entry.md5 = fmt.Sprintf("%x", h.Sum(nil))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseParagraph parses a single deb822 paragraph, returning false if it can't be parsed
+func parseParagraph(rdr io.Reader) (*Paragraph, bool) {
+	data, err := ioutil.ReadAll(rdr)
+	if err != nil {
+		return nil, false
+	}
+	paras, err := ParseDeb822(bytes.NewReader(data))
+	if err != nil || len(paras) == 0 {
+		return nil, false
+	}
+	return paras[0], true
+}
+
+func joinDiffPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + ":" + name
+}
+
+func diffEntry(path string, a, b *archiveEntry) []Difference {
+	diffs := []Difference{}
+	for i, field := range a.fields {
+		if i < len(b.fields) && field[1] != b.fields[i][1] {
+			diffs = append(diffs, Difference{path, field[0], field[1], b.fields[i][1]})
+		}
+	}
+	contentDiffs := []Difference{}
+	if a.para != nil && b.para != nil {
+		contentDiffs = diffParagraphs(path, a.para, b.para)
+	} else if a.isParsed && b.isParsed {
+		contentDiffs = diffEntries(path, a.entries, b.entries)
+	}
+	diffs = append(diffs, contentDiffs...)
+	// report the checksum only when the contents don't explain the difference (e.g. compression headers)
+	if len(contentDiffs) == 0 && a.md5 != b.md5 {
+		diffs = append(diffs, Difference{path, "md5", a.md5, b.md5})
+	}
+	return diffs
+}
+
+func diffEntries(path string, a, b []*archiveEntry) []Difference {
+	diffs := []Difference{}
+	bByName := map[string]*archiveEntry{}
+	for _, entry := range b {
+		bByName[entry.name] = entry
+	}
+	aByName := map[string]*archiveEntry{}
+	for _, entry := range a {
+		aByName[entry.name] = entry
+	}
+	// positions are compared among the entries common to both, so that a missing entry doesn't shift the rest
+	bPositions := map[string]int{}
+	for _, entry := range b {
+		if _, ok := aByName[entry.name]; ok {
+			bPositions[entry.name] = len(bPositions)
+		}
+	}
+	aPosition := 0
+	for _, entry := range a {
+		entryPath := joinDiffPath(path, entry.name)
+		other, ok := bByName[entry.name]
+		if !ok {
+			diffs = append(diffs, Difference{entryPath, "presence", "present", "absent"})
+			continue
+		}
+		if bPositions[entry.name] != aPosition {
+			diffs = append(diffs, Difference{entryPath, "position", fmt.Sprintf("%d", aPosition), fmt.Sprintf("%d", bPositions[entry.name])})
+		}
+		aPosition++
+		diffs = append(diffs, diffEntry(entryPath, entry, other)...)
+	}
+	for _, entry := range b {
+		if _, ok := aByName[entry.name]; !ok {
+			diffs = append(diffs, Difference{joinDiffPath(path, entry.name), "presence", "absent", "present"})
+		}
+	}
+	return diffs
+}
+
+func diffParagraphs(path string, a, b *Paragraph) []Difference {
+	diffs := []Difference{}
+	for _, field := range a.Fields {
+		value, ok := b.Get(field.Name)
+		if !ok {
+			diffs = append(diffs, Difference{path, "field " + field.Name, field.Value, ""})
+		} else if value != field.Value {
+			diffs = append(diffs, Difference{path, "field " + field.Name, field.Value, value})
+		}
+	}
+	for _, field := range b.Fields {
+		if _, ok := a.Get(field.Name); !ok {
+			diffs = append(diffs, Difference{path, "field " + field.Name, "", field.Value})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,87 @@
+package deb_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/targz"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func buildDiffTestDeb(t *testing.T, version string, files map[string]string, modTime time.Time) []byte {
+	writeTgz := func(entries [][2]string) *bytes.Buffer {
+		buf := new(bytes.Buffer)
+		tgzw := targz.NewWriter(buf)
+		for _, entry := range entries {
+			hdr := &tar.Header{Name: entry[0], Size: int64(len(entry[1])), Mode: 0644, ModTime: modTime, Typeflag: tar.TypeReg}
+			if err := tgzw.WriteHeader(hdr); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if _, err := tgzw.Write([]byte(entry[1])); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+		if err := tgzw.Close(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		return buf
+	}
+	control := writeTgz([][2]string{{"./control", "Package: testpkg\nVersion: " + version + "\n"}})
+	dataEntries := [][2]string{}
+	for _, name := range []string{"./usr/bin/a", "./usr/bin/b"} {
+		if content, ok := files[name]; ok {
+			dataEntries = append(dataEntries, [2]string{name, content})
+		}
+	}
+	data := writeTgz(dataEntries)
+	bdeb := deb.NewDebWriter(deb.NewPackage("testpkg", version, "me", "desc"), deb.ArchAmd64)
+	bdeb.ModTime = modTime
+	buf := new(bytes.Buffer)
+	err := bdeb.WriteAr(buf, control, int64(control.Len()), data, int64(data.Len()))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDiffDebs(t *testing.T) {
+	epoch := time.Unix(1500000000, 0)
+	a := buildDiffTestDeb(t, "1.0", map[string]string{"./usr/bin/a": "a", "./usr/bin/b": "b"}, epoch)
+	diffs, err := deb.DiffDebs(bytes.NewReader(a), bytes.NewReader(a))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("Expected no differences, got %v", diffs)
+	}
+
+	b := buildDiffTestDeb(t, "1.1", map[string]string{"./usr/bin/a": "A"}, epoch.Add(time.Second))
+	diffs, err = deb.DiffDebs(bytes.NewReader(a), bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := []deb.Difference{
+		{Path: "debian-binary", Field: "mtime", A: "1500000000", B: "1500000001"},
+		{Path: "control.tar.gz", Field: "mtime", A: "1500000000", B: "1500000001"},
+		{Path: "control.tar.gz:./control", Field: "mtime", A: "1500000000", B: "1500000001"},
+		{Path: "control.tar.gz:./control", Field: "field Version", A: "1.0", B: "1.1"},
+		{Path: "data.tar.gz", Field: "mtime", A: "1500000000", B: "1500000001"},
+	}
+	// sizes of compressed archives aren't predictable
+	actual := []deb.Difference{}
+	for _, diff := range diffs {
+		if diff.Field != "size" || diff.Path == "data.tar.gz:./usr/bin/a" {
+			actual = append(actual, diff)
+		}
+	}
+	expected = append(expected,
+		deb.Difference{Path: "data.tar.gz:./usr/bin/a", Field: "mtime", A: "1500000000", B: "1500000001"},
+		deb.Difference{Path: "data.tar.gz:./usr/bin/a", Field: "md5", A: "0cc175b9c0f1b6a831c399e269772661", B: "7fc56270e7a70fa81a5935b72eacbe29"},
+		deb.Difference{Path: "data.tar.gz:./usr/bin/b", Field: "presence", A: "present", B: "absent"},
+	)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected differences:\n%v\ngot:\n%v", expected, actual)
+	}
+}
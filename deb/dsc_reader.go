@@ -17,43 +17,43 @@
 package deb
 
 import (
-	"bufio"
+	"fmt"
 	"io"
-	"log"
-	"strings"
 )
 
 // DscReader reads a control file.
+// It can also read other deb822-format files, such as .changes files or Packages indices.
 type DscReader struct {
 	Reader io.Reader
 }
 
-//NewDscReader is a factory for reading Dsc files.
-func NewDscReader(rdr io.Reader) (*DscReader) {
+// NewDscReader is a factory for reading Dsc files.
+func NewDscReader(rdr io.Reader) *DscReader {
 	return &DscReader{rdr}
 }
 
-// Parse parses a file into a package.
+// Parse parses the first paragraph of a file into a package.
 func (dscr *DscReader) Parse() (*Package, error) {
-	pkg := &Package{}
-	br := bufio.NewReader(dscr.Reader)
-	for {
-		line, err := br.ReadString('\n')
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-		if strings.Contains(line, ":") {
-			res := strings.SplitN(line, ":", 2)
-			log.Printf("Control File entry: '%s': %s", res[0], res[1])
-			pkg.SetField(res[0], res[1])
-		} else {
-
-		}
+	para, err := NewDeb822Reader(dscr.Reader).Next()
+	if err == io.EOF {
+		return nil, fmt.Errorf("No control data found")
 	}
-	return pkg, nil
-
+	if err != nil {
+		return nil, err
+	}
+	return NewPackageFromControl(para), nil
 }
 
+// ParseAll parses each paragraph into a package.
+// This is useful for Packages indices, which contain one paragraph per package.
+func (dscr *DscReader) ParseAll() ([]*Package, error) {
+	paras, err := ParseDeb822(dscr.Reader)
+	if err != nil {
+		return nil, err
+	}
+	pkgs := []*Package{}
+	for _, para := range paras {
+		pkgs = append(pkgs, NewPackageFromControl(para))
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"strings"
+)
+
+// ControlFieldMapping associates a control field name with the Package member which stores it.
+type ControlFieldMapping struct {
+	Field  string // Official field name, e.g. 'Build-Depends'
+	Member string // Package member, e.g. 'BuildDepends'
+}
+
+// ControlFieldMappings lists each policy-defined control field which is stored in a dedicated Package member.
+// Other fields are stored in Package.AdditionalControlData.
+//
+// See https://www.debian.org/doc/debian-policy/ch-controlfields.html#list-of-fields
+var ControlFieldMappings = []ControlFieldMapping{
+	{"Package", "Name"},
+	{"Source", "Source"},
+	{"Version", "Version"},
+	{"Maintainer", "Maintainer"},
+	{"Uploaders", "Uploaders"},
+	{"Description", "Description"},
+	{"Architecture", "Architecture"},
+	{"Section", "Section"},
+	{"Priority", "Priority"},
+	{"Essential", "Essential"},
+	{"Installed-Size", "InstalledSize"},
+	{"Multi-Arch", "MultiArch"},
+	{"Homepage", "Homepage"},
+	{"Pre-Depends", "PreDepends"},
+	{"Depends", "Depends"},
+	{"Recommends", "Recommends"},
+	{"Suggests", "Suggests"},
+	{"Enhances", "Enhances"},
+	{"Breaks", "Breaks"},
+	{"Conflicts", "Conflicts"},
+	{"Provides", "Provides"},
+	{"Replaces", "Replaces"},
+	{"Built-Using", "BuiltUsing"},
+	{"Build-Depends", "BuildDepends"},
+	{"Build-Depends-Indep", "BuildDependsIndep"},
+	{"Build-Depends-Arch", "BuildDependsArch"},
+	{"Build-Conflicts", "BuildConflicts"},
+	{"Build-Conflicts-Indep", "ConflictsIndep"},
+	{"Build-Conflicts-Arch", "BuildConflictsArch"},
+	{"Standards-Version", "StandardsVersion"},
+	{"Format", "Format"},
+	{"Binary", "Binary"},
+	{"Vcs-Browser", "VcsBrowser"},
+	{"Vcs-Git", "VcsGit"},
+	{"Testsuite", "Testsuite"},
+	{"Rules-Requires-Root", "RulesRequiresRoot"},
+	{"Origin", "Origin"},
+	{"Bugs", "Bugs"},
+}
+
+// legacyFieldNames are names previously accepted by Package.SetField. They are matched case-sensitively.
+// Status and Other aren't control fields; they hold template data (e.g. the changelog's distribution).
+var legacyFieldNames = map[string]string{
+	"BuildDepends":     "BuildDepends",
+	"StandardsVersion": "StandardsVersion",
+	"Status":           "Status",
+	"Other":            "Other",
+}
+
+// ControlFieldMember returns the name of the Package member which stores the given control field.
+// The field name is matched case-insensitively.
+func ControlFieldMember(field string) (string, bool) {
+	for _, mapping := range ControlFieldMappings {
+		if strings.EqualFold(mapping.Field, field) {
+			return mapping.Member, true
+		}
+	}
+	member, ok := legacyFieldNames[field]
+	return member, ok
+}
+
+// ControlFieldName returns the official control field name for the given Package member.
+func ControlFieldName(member string) (string, bool) {
+	for _, mapping := range ControlFieldMappings {
+		if mapping.Member == member {
+			return mapping.Field, true
+		}
+	}
+	return "", false
+}
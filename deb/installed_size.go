@@ -0,0 +1,48 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"archive/tar"
+	"strconv"
+)
+
+// InstalledSizeKiB estimates the disk usage of a data archive entry, in KiB, using dpkg-gencontrol's rules.
+// Regular files are rounded up to a whole KiB each.
+// Hard links count nothing, because the linked file is already counted.
+// Directories, symlinks and other entries count 1 KiB each.
+func InstalledSizeKiB(hdr *tar.Header) int64 {
+	switch hdr.Typeflag {
+	case tar.TypeReg, tar.TypeRegA:
+		return (hdr.Size + 1023) / 1024
+	case tar.TypeLink:
+		return 0
+	}
+	return 1
+}
+
+// InstalledSizeField returns the Installed-Size control field's value.
+// Package.InstalledSize takes precedence over the computed InstalledSize. Empty if neither is known.
+func (bdeb *DebWriter) InstalledSizeField() string {
+	if bdeb.Package.InstalledSize != "" {
+		return bdeb.Package.InstalledSize
+	}
+	if bdeb.InstalledSize > 0 {
+		return strconv.FormatInt(bdeb.InstalledSize, 10)
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package deb_test
+
+import (
+	"archive/tar"
+	"github.com/laher/debgo-v0.2/deb"
+	"testing"
+)
+
+func TestInstalledSizeKiB(t *testing.T) {
+	sizes := []struct {
+		hdr      *tar.Header
+		expected int64
+	}{
+		{&tar.Header{Typeflag: tar.TypeReg, Size: 0}, 0},
+		{&tar.Header{Typeflag: tar.TypeReg, Size: 1}, 1},
+		{&tar.Header{Typeflag: tar.TypeReg, Size: 1024}, 1},
+		{&tar.Header{Typeflag: tar.TypeReg, Size: 1025}, 2},
+		{&tar.Header{Typeflag: tar.TypeDir}, 1},
+		{&tar.Header{Typeflag: tar.TypeSymlink, Linkname: "a"}, 1},
+		{&tar.Header{Typeflag: tar.TypeLink, Linkname: "a", Size: 4096}, 0},
+	}
+	for _, s := range sizes {
+		if actual := deb.InstalledSizeKiB(s.hdr); actual != s.expected {
+			t.Errorf("%+v: expected %d, got %d", s.hdr, s.expected, actual)
+		}
+	}
+}
+
+func TestInstalledSizeField(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me", "lovely package\n")
+	bdeb := deb.NewDebWriter(pkg, deb.ArchAmd64)
+	if _, exists := bdeb.ControlParagraph().Get("Installed-Size"); exists {
+		t.Errorf("Installed-Size should be omitted when unknown")
+	}
+	bdeb.InstalledSize = 12
+	if value, _ := bdeb.ControlParagraph().Get("Installed-Size"); value != "12" {
+		t.Errorf("Expected computed Installed-Size 12, got '%s'", value)
+	}
+	pkg.InstalledSize = "100"
+	if value, _ := bdeb.ControlParagraph().Get("Installed-Size"); value != "100" {
+		t.Errorf("Expected overridden Installed-Size 100, got '%s'", value)
+	}
+}
@@ -0,0 +1,53 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Md5SumsPath converts a path in the data archive (e.g. '/usr/bin/foo' or './usr/bin/foo') to the form used in md5sums files ('usr/bin/foo').
+func Md5SumsPath(path string) string {
+	path = strings.Replace(path, "\\", "/", -1)
+	path = strings.TrimPrefix(path, ".")
+	return strings.TrimLeft(path, "/")
+}
+
+// WriteMd5Sums writes an md5sums control file, sorted by path.
+// md5sums maps each path (see Md5SumsPath) to the hex-encoded MD5 digest of its contents.
+func WriteMd5Sums(w io.Writer, md5sums map[string]string) error {
+	normalised := map[string]string{}
+	paths := make([]string, 0, len(md5sums))
+	for path, sum := range md5sums {
+		path = Md5SumsPath(path)
+		normalised[path] = sum
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	bw := bufio.NewWriter(w)
+	for _, path := range paths {
+		_, err := fmt.Fprintf(bw, "%s  %s\n", normalised[path], path)
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
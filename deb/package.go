@@ -28,10 +28,11 @@ type Package struct {
 	Version     string // Package version
 	Description string // Description
 	Maintainer  string // Maintainer
+	Uploaders   string // Co-maintainers (source packages)
 
-	AdditionalControlData map[string]string // Other key/values to go into the Control file.
+	AdditionalControlData Paragraph // Other fields to go into the Control file, in order.
 
-	Architecture string // Supported values: "all", "x386", "amd64", "armhf". TODO: armel
+	Architecture string // e.g. "all", "any", "amd64", "linux-any". See Architecture
 
 	Depends    string // Depends
 	Recommends string
@@ -43,18 +44,35 @@ type Package struct {
 	Provides   string
 	Replaces   string
 
-	BuildDepends      string // BuildDepends is only required for "sourcedebs".
-	BuildDependsIndep string
-	ConflictsIndep    string
-	BuiltUsing        string
-
-	Priority         string
-	StandardsVersion string
-	Section          string
-	Format           string
-	Status           string
-	Other            string
-	Source           string
+	BuildDepends       string // BuildDepends is only required for "sourcedebs".
+	BuildDependsIndep  string
+	BuildDependsArch   string
+	BuildConflicts     string
+	ConflictsIndep     string // Build-Conflicts-Indep
+	BuildConflictsArch string
+	BuiltUsing         string
+
+	Priority          string
+	StandardsVersion  string
+	Section           string
+	Format            string
+	Status            string
+	Other             string
+	Source            string
+	Binary            string // List of binary packages (.dsc files)
+	Homepage          string
+	Essential         string // "yes" or "no"
+	MultiArch         string // "same", "foreign", "allowed" or "no"
+	InstalledSize     string // Estimated installed size in KiB. Overrides the size computed for binary packages
+	VcsBrowser        string
+	VcsGit            string
+	Testsuite         string
+	RulesRequiresRoot string
+	Origin            string
+	Bugs              string
+
+	SystemUsers  []*SystemUser  // System users created by the maintainer scripts (binary packages)
+	SystemGroups []*SystemGroup // System groups created by the maintainer scripts (binary packages)
 
 	ExtraData map[string]interface{} // Optional for templates
 
@@ -72,6 +90,16 @@ func NewPackage(name, version, maintainer, description string) *Package {
 	return pkg
 }
 
+// NewPackageFromControl is a factory for a Package, populated from a parsed control paragraph.
+// No defaults are applied.
+func NewPackageFromControl(para *Paragraph) *Package {
+	pkg := &Package{}
+	for _, field := range para.Fields {
+		pkg.SetField(field.Name, field.Value)
+	}
+	return pkg
+}
+
 // Sets fields which can be initialised appropriately
 func SetDefaults(pkg *Package) {
 	pkg.Architecture = "any" //default ...
@@ -83,47 +111,40 @@ func SetDefaults(pkg *Package) {
 	//pkg.MappedFiles = map[string]string{}
 }
 
-// GetArches resolves architecture(s) and return as a slice
+// GetArches resolves architecture(s) and return as a slice.
+// Wildcards such as 'any' resolve to DefaultArchitectures, and those such as 'any-arm64' which match none of them resolve to GoArchitectures
 func (pkg *Package) GetArches() ([]Architecture, error) {
-	arches, err := resolveArches(pkg.Architecture)
+	return resolveArches(pkg.Architecture, DefaultArchitectures, GoArchitectures)
+}
+
+// ResolveArches resolves architecture(s) and return as a slice.
+// Wildcards such as 'any' resolve to those candidates which match.
+func (pkg *Package) ResolveArches(candidates []Architecture) ([]Architecture, error) {
+	arches, err := resolveArches(pkg.Architecture, candidates, nil)
 	return arches, err
 }
 
-// SetField sets a control field by name
-// Unrecognised keys are added to AdditionalControlData
+// SetField sets a control field by name.
+// Names are matched case-insensitively against the policy-defined fields (see ControlFieldMember).
+// Unrecognised keys are added to AdditionalControlData, retaining their order.
 func (pkg *Package) SetField(key, value string) {
-	switch key {
-	case "Package":
-		pkg.Name = value
-	case "Source":
-		pkg.Source = value
-	case "Version":
-		pkg.Version = value
-	case "Description":
-		pkg.Description = value
-	case "Maintainer":
-		pkg.Maintainer = value
-	case "Architecture":
-		pkg.Architecture = value
-	case "Depends":
-		pkg.Depends = value
-	case "BuildDepends":
-		pkg.BuildDepends = value
-	case "Priority":
-		pkg.Priority = value
-	case "StandardsVersion":
-		pkg.StandardsVersion = value
-	case "Section":
-		pkg.Section = value
-	case "Format":
-		pkg.Format = value
-	case "Status":
-		pkg.Status = value
-	case "Other":
-		pkg.Other = value
-	default:
-		pkg.AdditionalControlData[key] = value
+	member, ok := ControlFieldMember(key)
+	if ok {
+		reflect.ValueOf(pkg).Elem().FieldByName(member).SetString(value)
+		return
+	}
+	pkg.AdditionalControlData.Set(key, value)
+}
+
+// GetField gets a control field by name.
+// Names are matched case-insensitively. Unrecognised keys are looked up in AdditionalControlData
+func (pkg *Package) GetField(key string) string {
+	member, ok := ControlFieldMember(key)
+	if ok {
+		return reflect.ValueOf(pkg).Elem().FieldByName(member).String()
 	}
+	value, _ := pkg.AdditionalControlData.Get(key)
+	return value
 }
 
 func Copy(pkg *Package) *Package {
@@ -138,5 +159,7 @@ func Copy(pkg *Package) *Package {
 		log.Printf("%v => %v", source, dest)
 		dest.Set(source)
 	}
+	// don't share the ordered fields with the original
+	npkg.AdditionalControlData.Fields = append([]ControlField{}, pkg.AdditionalControlData.Fields...)
 	return npkg
 }
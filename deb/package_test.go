@@ -18,6 +18,7 @@ package deb_test
 
 import (
 	"github.com/laher/debgo-v0.2/deb"
+	"strings"
 	"testing"
 )
 
@@ -33,3 +34,89 @@ func TestCopy(t *testing.T) {
 	t.Logf("Original: %+v", pkg)
 	t.Logf("Copy:     %+v", npkg)
 }
+
+func TestSetField(t *testing.T) {
+	pkg := &deb.Package{}
+	fields := map[string]string{
+		"Package":           "a",
+		"build-depends":     "debhelper",
+		"Standards-Version": "3.9.4",
+		"PRE-DEPENDS":       "dpkg",
+		"Homepage":          "http://example.com",
+		"Multi-Arch":        "foreign",
+		"Essential":         "no",
+		"Installed-Size":    "12",
+		"X-Custom":          "yes",
+	}
+	for k, v := range fields {
+		pkg.SetField(k, v)
+	}
+	if pkg.Name != "a" || pkg.BuildDepends != "debhelper" || pkg.StandardsVersion != "3.9.4" || pkg.PreDepends != "dpkg" {
+		t.Errorf("Fields not mapped to members: %+v", pkg)
+	}
+	if pkg.Homepage != "http://example.com" || pkg.MultiArch != "foreign" || pkg.Essential != "no" || pkg.InstalledSize != "12" {
+		t.Errorf("Fields not mapped to members: %+v", pkg)
+	}
+	if len(pkg.AdditionalControlData.Fields) != 1 {
+		t.Errorf("Expected only one additional field: %v", pkg.AdditionalControlData)
+	}
+	for k, v := range fields {
+		if pkg.GetField(k) != v {
+			t.Errorf("GetField(%s): expected %s, got %s", k, v, pkg.GetField(k))
+		}
+	}
+	pkg.SetField("x-custom", "no")
+	if pkg.GetField("X-Custom") != "no" || len(pkg.AdditionalControlData.Fields) != 1 {
+		t.Errorf("Additional fields should be matched case-insensitively: %v", pkg.AdditionalControlData)
+	}
+	// legacy names
+	pkg.SetField("BuildDepends", "golang-go")
+	if pkg.BuildDepends != "golang-go" {
+		t.Errorf("Legacy field name not mapped")
+	}
+}
+
+func TestSetFieldLegacyKeys(t *testing.T) {
+	// the keys accepted by earlier versions of SetField
+	keys := []string{"Package", "Source", "Version", "Description", "Maintainer", "Architecture", "Depends",
+		"BuildDepends", "Priority", "StandardsVersion", "Section", "Format", "Status", "Other"}
+	pkg := deb.NewPackage("a", "1.0", "me", "desc")
+	for _, key := range keys {
+		pkg.SetField(key, "value-"+key)
+		if pkg.GetField(key) != "value-"+key {
+			t.Errorf("GetField(%s): expected %s, got %s", key, "value-"+key, pkg.GetField(key))
+		}
+	}
+	if len(pkg.AdditionalControlData.Fields) != 0 {
+		t.Errorf("Expected no additional fields, got %v", pkg.AdditionalControlData)
+	}
+	if pkg.Status != "value-Status" || pkg.Other != "value-Other" {
+		t.Errorf("Status and Other not mapped to members: %+v", pkg)
+	}
+}
+
+func TestControlFieldMappings(t *testing.T) {
+	for _, mapping := range deb.ControlFieldMappings {
+		member, ok := deb.ControlFieldMember(strings.ToLower(mapping.Field))
+		if !ok || member != mapping.Member {
+			t.Errorf("%s not mapped to %s", mapping.Field, mapping.Member)
+		}
+		field, ok := deb.ControlFieldName(mapping.Member)
+		if !ok || field != mapping.Field {
+			t.Errorf("%s not mapped to %s", mapping.Member, mapping.Field)
+		}
+		// panics if the member doesn't exist
+		pkg := &deb.Package{}
+		pkg.SetField(mapping.Field, "x")
+	}
+}
+
+func TestCopyAdditionalFields(t *testing.T) {
+	pkg := deb.NewPackage("a", "1", "me", "desc")
+	pkg.SetField("X-A", "1")
+	npkg := deb.Copy(pkg)
+	npkg.SetField("X-A", "2")
+	if pkg.GetField("X-A") != "1" {
+		t.Errorf("Copy shares additional fields with the original")
+	}
+}
@@ -0,0 +1,303 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Relations is the parsed form of a relationship field such as Depends or Build-Depends.
+// It is a list of Alternatives, all of which must be satisfied (i.e. separated by commas).
+//
+// See https://www.debian.org/doc/debian-policy/ch-relationships.html
+type Relations []Alternatives
+
+// Alternatives is a list of relations, any one of which may satisfy the dependency (i.e. separated by '|').
+type Alternatives []*Relation
+
+// Relation is a single package relationship, e.g. 'libc6:any (>= 2.3) [amd64 !i386] <!nocheck>'
+type Relation struct {
+	Name          string                 // Package name, or a substitution variable such as ${misc:Depends}
+	ArchQualifier string                 // Optional. e.g. 'any' or 'native'
+	Operator      string                 // Optional. One of '<<', '<=', '=', '>=', '>>'
+	Version       string                 // Required when Operator is set
+	Architectures []ArchRestriction      // Optional. e.g. [amd64 !i386]
+	Profiles      [][]ProfileRestriction // Optional. Each element is one <...> formula. Formulas are OR-ed, terms within a formula are AND-ed.
+}
+
+// ArchRestriction is one element of an architecture restriction list.
+type ArchRestriction struct {
+	Architecture string
+	Negated      bool
+}
+
+// ProfileRestriction is one term of a build profile restriction formula.
+type ProfileRestriction struct {
+	Profile string
+	Negated bool
+}
+
+var (
+	relationRegexp     = regexp.MustCompile(`^([^\s:(\[<]+)(?::(\S+?))?\s*(?:\(\s*([<=>]+)\s*([^\s)]*)\s*\))?\s*(?:\[([^\]]*)\])?\s*((?:<[^>]*>\s*)*)$`)
+	relationNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9+.-]+$`)
+	substvarRegexp     = regexp.MustCompile(`^\$\{[A-Za-z0-9:-]+\}$`)
+	archNameRegexp     = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	profileNameRegexp  = regexp.MustCompile(`^[a-z0-9][a-z0-9.+-]*$`)
+
+	relationOperators = []string{"<<", "<=", "=", ">=", ">>"}
+)
+
+// ParseRelations parses the contents of a relationship field.
+// Empty elements (e.g. a trailing comma) are ignored.
+func ParseRelations(value string) (Relations, error) {
+	rels := Relations{}
+	for _, and := range strings.Split(value, ",") {
+		if strings.TrimSpace(and) == "" {
+			continue
+		}
+		alts := Alternatives{}
+		for _, or := range strings.Split(and, "|") {
+			rel, err := ParseRelation(or)
+			if err != nil {
+				return nil, err
+			}
+			alts = append(alts, rel)
+		}
+		rels = append(rels, alts)
+	}
+	return rels, nil
+}
+
+// ParseRelation parses a single relation (no commas or '|' characters).
+func ParseRelation(value string) (*Relation, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("Empty relation")
+	}
+	if substvarRegexp.MatchString(value) {
+		return &Relation{Name: value}, nil
+	}
+	submatches := relationRegexp.FindStringSubmatch(value)
+	if submatches == nil {
+		return nil, fmt.Errorf("Invalid relation '%s'", value)
+	}
+	rel := &Relation{Name: submatches[1], ArchQualifier: submatches[2], Operator: submatches[3], Version: submatches[4]}
+	if !relationNameRegexp.MatchString(rel.Name) {
+		return nil, fmt.Errorf("Invalid package name '%s' in relation '%s'", rel.Name, value)
+	}
+	if rel.ArchQualifier != "" && !archNameRegexp.MatchString(rel.ArchQualifier) {
+		return nil, fmt.Errorf("Invalid architecture qualifier '%s' in relation '%s'", rel.ArchQualifier, value)
+	}
+	if rel.Operator != "" {
+		if rel.Operator == "<" || rel.Operator == ">" {
+			return nil, fmt.Errorf("Obsolete relation operator '%s' in relation '%s'. Use '%s%s' or '%s='", rel.Operator, value, rel.Operator, rel.Operator, rel.Operator)
+		}
+		if !isRelationOperator(rel.Operator) {
+			return nil, fmt.Errorf("Invalid relation operator '%s' in relation '%s'", rel.Operator, value)
+		}
+		if err := ValidateVersion(rel.Version); err != nil {
+			return nil, fmt.Errorf("Invalid version in relation '%s': %v", value, err)
+		}
+	}
+	if submatches[5] != "" || strings.Contains(value, "[") {
+		archs := strings.Fields(submatches[5])
+		if len(archs) == 0 {
+			return nil, fmt.Errorf("Empty architecture restriction list in relation '%s'", value)
+		}
+		for _, arch := range archs {
+			restriction := ArchRestriction{Architecture: strings.TrimPrefix(arch, "!"), Negated: strings.HasPrefix(arch, "!")}
+			if !archNameRegexp.MatchString(restriction.Architecture) {
+				return nil, fmt.Errorf("Invalid architecture '%s' in relation '%s'", arch, value)
+			}
+			if len(rel.Architectures) > 0 && rel.Architectures[0].Negated != restriction.Negated {
+				return nil, fmt.Errorf("Architecture restriction list mixes negated and non-negated architectures in relation '%s'", value)
+			}
+			rel.Architectures = append(rel.Architectures, restriction)
+		}
+	}
+	if submatches[6] != "" {
+		for _, formula := range strings.Split(submatches[6], ">") {
+			formula = strings.TrimSpace(formula)
+			if formula == "" {
+				continue
+			}
+			terms := strings.Fields(strings.TrimPrefix(formula, "<"))
+			if len(terms) == 0 {
+				return nil, fmt.Errorf("Empty build profile restriction in relation '%s'", value)
+			}
+			restrictions := []ProfileRestriction{}
+			for _, term := range terms {
+				restriction := ProfileRestriction{Profile: strings.TrimPrefix(term, "!"), Negated: strings.HasPrefix(term, "!")}
+				if !profileNameRegexp.MatchString(restriction.Profile) {
+					return nil, fmt.Errorf("Invalid build profile '%s' in relation '%s'", term, value)
+				}
+				restrictions = append(restrictions, restriction)
+			}
+			rel.Profiles = append(rel.Profiles, restrictions)
+		}
+	}
+	return rel, nil
+}
+
+func isRelationOperator(op string) bool {
+	for _, o := range relationOperators {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the relation in canonical form
+func (rel *Relation) String() string {
+	s := rel.Name
+	if rel.ArchQualifier != "" {
+		s += ":" + rel.ArchQualifier
+	}
+	if rel.Operator != "" {
+		s += " (" + rel.Operator + " " + rel.Version + ")"
+	}
+	if len(rel.Architectures) > 0 {
+		archs := []string{}
+		for _, arch := range rel.Architectures {
+			archs = append(archs, arch.String())
+		}
+		s += " [" + strings.Join(archs, " ") + "]"
+	}
+	for _, formula := range rel.Profiles {
+		terms := []string{}
+		for _, term := range formula {
+			terms = append(terms, term.String())
+		}
+		s += " <" + strings.Join(terms, " ") + ">"
+	}
+	return s
+}
+
+func (arch ArchRestriction) String() string {
+	if arch.Negated {
+		return "!" + arch.Architecture
+	}
+	return arch.Architecture
+}
+
+func (profile ProfileRestriction) String() string {
+	if profile.Negated {
+		return "!" + profile.Profile
+	}
+	return profile.Profile
+}
+
+// String formats the alternatives in canonical form
+func (alts Alternatives) String() string {
+	s := []string{}
+	for _, rel := range alts {
+		s = append(s, rel.String())
+	}
+	return strings.Join(s, " | ")
+}
+
+// String formats the relations in canonical form
+func (rels Relations) String() string {
+	s := []string{}
+	for _, alts := range rels {
+		s = append(s, alts.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+// relationFieldRule describes the syntax allowed within a given relationship field.
+type relationFieldRule struct {
+	AllowsAlternatives bool     // Whether '|' is permitted
+	Operators          []string // Permitted version operators. nil means all.
+}
+
+var relationFieldRules = map[string]relationFieldRule{
+	"Pre-Depends":           {AllowsAlternatives: true},
+	"Depends":               {AllowsAlternatives: true},
+	"Recommends":            {AllowsAlternatives: true},
+	"Suggests":              {AllowsAlternatives: true},
+	"Enhances":              {AllowsAlternatives: true},
+	"Breaks":                {},
+	"Conflicts":             {},
+	"Replaces":              {},
+	"Provides":              {Operators: []string{"="}},
+	"Build-Depends":         {AllowsAlternatives: true},
+	"Build-Depends-Indep":   {AllowsAlternatives: true},
+	"Build-Depends-Arch":    {AllowsAlternatives: true},
+	"Build-Conflicts":       {},
+	"Build-Conflicts-Indep": {},
+	"Build-Conflicts-Arch":  {},
+	"Built-Using":           {Operators: []string{"="}},
+}
+
+// ValidateRelations parses a relationship field and checks it against the rules for that particular field.
+// e.g. alternatives are not permitted in 'Conflicts', and 'Provides' only permits the '=' operator.
+// An empty value is valid.
+func ValidateRelations(field, value string) error {
+	rels, err := ParseRelations(value)
+	if err != nil {
+		return fmt.Errorf("%s: %v", field, err)
+	}
+	rule, ok := relationFieldRules[field]
+	if !ok {
+		return nil
+	}
+	for _, alts := range rels {
+		if len(alts) > 1 && !rule.AllowsAlternatives {
+			return fmt.Errorf("%s: alternatives are not permitted ('%s')", field, alts)
+		}
+		for _, rel := range alts {
+			if rel.Operator == "" || rule.Operators == nil {
+				continue
+			}
+			permitted := false
+			for _, op := range rule.Operators {
+				if op == rel.Operator {
+					permitted = true
+				}
+			}
+			if !permitted {
+				return fmt.Errorf("%s: operator '%s' is not permitted ('%s')", field, rel.Operator, rel)
+			}
+		}
+	}
+	return nil
+}
+
+// AddRelation appends a relation to a relationship field's value (e.g. Depends), unless the field already has a relation on the same package.
+func AddRelation(value, relation string) string {
+	rel, err := ParseRelation(relation)
+	if err == nil {
+		rels, err := ParseRelations(value)
+		if err == nil {
+			for _, alts := range rels {
+				for _, existing := range alts {
+					if existing.Name == rel.Name {
+						return value
+					}
+				}
+			}
+		}
+	}
+	if strings.TrimSpace(value) == "" {
+		return relation
+	}
+	return value + ", " + relation
+}
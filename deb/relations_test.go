@@ -0,0 +1,154 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb_test
+
+import (
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"log"
+	"testing"
+)
+
+func ExampleParseRelations() {
+	rels, err := deb.ParseRelations("libc6 (>= 2.14), golang-go | gccgo:native [!armel] <!nocheck>")
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	for _, alts := range rels {
+		for _, rel := range alts {
+			fmt.Printf("%s %s %s %v\n", rel.Name, rel.Operator, rel.Version, rel.Architectures)
+		}
+	}
+
+	// Output:
+	// libc6 >= 2.14 []
+	// golang-go   []
+	// gccgo   [!armel]
+}
+
+func TestParseRelations(t *testing.T) {
+	rels, err := deb.ParseRelations("foo:any (>= 1:1.0-1) [amd64 i386] <!nocheck cross> <stage1>, bar | baz (<< 2)")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(rels) != 2 || len(rels[0]) != 1 || len(rels[1]) != 2 {
+		t.Fatalf("Unexpected structure: %v", rels)
+	}
+	foo := rels[0][0]
+	if foo.Name != "foo" || foo.ArchQualifier != "any" || foo.Operator != ">=" || foo.Version != "1:1.0-1" {
+		t.Errorf("Unexpected relation: %+v", foo)
+	}
+	if len(foo.Architectures) != 2 || foo.Architectures[1].Architecture != "i386" || foo.Architectures[1].Negated {
+		t.Errorf("Unexpected architecture restrictions: %+v", foo.Architectures)
+	}
+	if len(foo.Profiles) != 2 || len(foo.Profiles[0]) != 2 || !foo.Profiles[0][0].Negated || foo.Profiles[1][0].Profile != "stage1" {
+		t.Errorf("Unexpected build profiles: %+v", foo.Profiles)
+	}
+	if rels[1][1].Name != "baz" || rels[1][1].Operator != "<<" || rels[1][1].Version != "2" {
+		t.Errorf("Unexpected relation: %+v", rels[1][1])
+	}
+}
+
+func TestRelationsRoundTrip(t *testing.T) {
+	canonical := map[string]string{
+		"foo":                             "foo",
+		"foo,bar":                         "foo, bar",
+		"foo (>=1.0)|bar":                 "foo (>= 1.0) | bar",
+		"foo:amd64(<<2.0-1)":              "foo:amd64 (<< 2.0-1)",
+		"foo [ !i386  !armhf ]":           "foo [!i386 !armhf]",
+		"foo <!nocheck><stage1  cross>":   "foo <!nocheck> <stage1 cross>",
+		"${misc:Depends}, foo,":           "${misc:Depends}, foo",
+		"debhelper (>= 9.1.0), golang-go": "debhelper (>= 9.1.0), golang-go",
+	}
+	for in, expected := range canonical {
+		rels, err := deb.ParseRelations(in)
+		if err != nil {
+			t.Fatalf("Error parsing '%s': %v", in, err)
+		}
+		if rels.String() != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, rels.String())
+		}
+		reparsed, err := deb.ParseRelations(rels.String())
+		if err != nil {
+			t.Fatalf("Error re-parsing '%s': %v", rels.String(), err)
+		}
+		if reparsed.String() != expected {
+			t.Errorf("Round trip of '%s' produced '%s'", expected, reparsed.String())
+		}
+	}
+}
+
+func TestParseRelationsInvalid(t *testing.T) {
+	invalid := []string{
+		"Foo",
+		"f",
+		"foo (> 1.0)",
+		"foo (>= )",
+		"foo (~ 1.0)",
+		"foo (>= a1)",
+		"foo | , bar",
+		"foo []",
+		"foo [amd64 !i386]",
+		"foo <>",
+		"foo <Bad>",
+		"${misc:Depends} (>= 1)",
+		"foo bar",
+	}
+	for _, in := range invalid {
+		_, err := deb.ParseRelations(in)
+		if err == nil {
+			t.Errorf("Invalid relation not detected: '%s'", in)
+		}
+	}
+}
+
+func TestValidatePackageRelations(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "1.0", "me", "desc")
+	pkg.Depends = "foo (>= 1.0) | bar"
+	pkg.Provides = "baz (= 1.0)"
+	err := deb.ValidatePackage(pkg)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg.Conflicts = "foo | bar"
+	if deb.ValidatePackage(pkg) == nil {
+		t.Errorf("Alternatives should not be permitted in Conflicts")
+	}
+	pkg.Conflicts = ""
+	pkg.Provides = "baz (>= 1.0)"
+	if deb.ValidatePackage(pkg) == nil {
+		t.Errorf("Only '=' should be permitted in Provides")
+	}
+	pkg.Provides = ""
+	pkg.Depends = "foo (>> 1.0"
+	if deb.ValidatePackage(pkg) == nil {
+		t.Errorf("Malformed Depends not detected")
+	}
+	pkg.Depends = ""
+	fields := []string{"Pre-Depends", "Depends", "Recommends", "Suggests", "Enhances", "Breaks", "Conflicts", "Replaces", "Provides",
+		"Build-Depends", "Build-Depends-Indep", "Build-Depends-Arch", "Build-Conflicts", "Build-Conflicts-Indep", "Build-Conflicts-Arch", "Built-Using"}
+	for _, field := range fields {
+		if _, ok := deb.ControlFieldMember(field); !ok {
+			t.Errorf("%s has no Package member", field)
+		}
+		pkg.SetField(field, "foo (>> 1.0")
+		if deb.ValidatePackage(pkg) == nil {
+			t.Errorf("Malformed %s not detected", field)
+		}
+		pkg.SetField(field, "")
+	}
+}
@@ -0,0 +1,99 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// SpoolThresholdDefault is the amount of data a Spool holds in memory before spilling to a temporary file.
+const SpoolThresholdDefault = 8 * 1024 * 1024
+
+// Spool buffers data whose size must be known before it can be written (e.g. members of an ar archive).
+// Data is held in memory up to Threshold bytes, then spills over into a uniquely-named temporary file.
+type Spool struct {
+	TmpDir    string // Directory for the temporary file. Empty for the system default
+	Threshold int64  // Maximum bytes held in memory
+
+	buf  bytes.Buffer
+	file *os.File
+	size int64
+}
+
+// NewSpool is a factory for Spool.
+func NewSpool(tmpDir string, threshold int64) *Spool {
+	return &Spool{TmpDir: tmpDir, Threshold: threshold}
+}
+
+// Write appends to the spool.
+func (s *Spool) Write(p []byte) (int, error) {
+	if s.file == nil && s.size+int64(len(p)) > s.Threshold {
+		f, err := ioutil.TempFile(s.TmpDir, "debgo-spool-")
+		if err != nil {
+			return 0, err
+		}
+		s.file = f
+		_, err = s.buf.WriteTo(f)
+		if err != nil {
+			return 0, err
+		}
+	}
+	var n int
+	var err error
+	if s.file != nil {
+		n, err = s.file.Write(p)
+	} else {
+		n, err = s.buf.Write(p)
+	}
+	s.size += int64(n)
+	return n, err
+}
+
+// Size returns the number of bytes written.
+func (s *Spool) Size() int64 {
+	return s.size
+}
+
+// Reader returns a reader for the spooled data, from the beginning.
+func (s *Spool) Reader() (io.Reader, error) {
+	if s.file == nil {
+		return bytes.NewReader(s.buf.Bytes()), nil
+	}
+	_, err := s.file.Seek(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.file, nil
+}
+
+// Close discards the spooled data, removing any temporary file.
+func (s *Spool) Close() error {
+	s.buf.Reset()
+	if s.file == nil {
+		return nil
+	}
+	name := s.file.Name()
+	err := s.file.Close()
+	s.file = nil
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+	return err
+}
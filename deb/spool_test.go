@@ -0,0 +1,48 @@
+package deb_test
+
+import (
+	"bytes"
+	"github.com/laher/debgo-v0.2/deb"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSpool(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "debgo-spool")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	for _, threshold := range []int64{1024, 10} {
+		spool := deb.NewSpool(tmpDir, threshold)
+		expected := []byte{}
+		for _, chunk := range []string{"hello ", "spooled ", "world"} {
+			if _, err = spool.Write([]byte(chunk)); err != nil {
+				t.Fatalf("%v", err)
+			}
+			expected = append(expected, chunk...)
+		}
+		if spool.Size() != int64(len(expected)) {
+			t.Errorf("Expected size %d, got %d", len(expected), spool.Size())
+		}
+		rdr, err := spool.Reader()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		read, err := ioutil.ReadAll(rdr)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if !bytes.Equal(read, expected) {
+			t.Errorf("Threshold %d: expected '%s', got '%s'", threshold, expected, read)
+		}
+		if err = spool.Close(); err != nil {
+			t.Errorf("%v", err)
+		}
+		fis, _ := ioutil.ReadDir(tmpDir)
+		if len(fis) != 0 {
+			t.Errorf("Threshold %d: temporary file not removed", threshold)
+		}
+	}
+}
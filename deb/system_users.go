@@ -0,0 +1,132 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+// Defaults for system users
+const (
+	SystemUserHomeDefault  = "/nonexistent"
+	SystemUserShellDefault = "/usr/sbin/nologin"
+)
+
+var (
+	systemAccountNameRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+	unsafePathRegexp        = regexp.MustCompile(`['"\s]`)
+	unsafeGecosRegexp       = regexp.MustCompile(`['":\n]`)
+)
+
+// SystemUser describes a system account which a package's maintainer scripts create (see Package.SystemUsers)
+type SystemUser struct {
+	Name            string
+	Group           string   // Primary group, created if necessary. Default: the same as Name
+	Home            string   // Home directory, created if it's not SystemUserHomeDefault
+	Shell           string   // Default SystemUserShellDefault
+	Gecos           string   // Optional description
+	Groups          []string // Supplementary groups, which must already exist (or be in Package.SystemGroups)
+	IsRemoveOnPurge bool     // Whether to delete the user when the package is purged
+}
+
+// SystemGroup describes a system group which a package's maintainer scripts create (see Package.SystemGroups)
+type SystemGroup struct {
+	Name            string
+	IsRemoveOnPurge bool // Whether to delete the group when the package is purged
+}
+
+// NewSystemUser is a factory for SystemUser, with a group of the same name, no home directory and no login shell.
+func NewSystemUser(name string) *SystemUser {
+	return &SystemUser{Name: name, Group: name, Home: SystemUserHomeDefault, Shell: SystemUserShellDefault, Groups: []string{}}
+}
+
+// NewSystemGroup is a factory for SystemGroup
+func NewSystemGroup(name string) *SystemGroup {
+	return &SystemGroup{Name: name}
+}
+
+// ValidateSystemAccountName checks a user or group name
+func ValidateSystemAccountName(name string) error {
+	if !systemAccountNameRegexp.MatchString(name) {
+		return fmt.Errorf("Invalid user or group name '%s'", name)
+	}
+	return nil
+}
+
+// Validate checks the user's name, groups, home and shell
+func (user *SystemUser) Validate() error {
+	err := ValidateSystemAccountName(user.Name)
+	if err != nil {
+		return err
+	}
+	for _, group := range append([]string{user.Group}, user.Groups...) {
+		if group == "" {
+			continue
+		}
+		err = ValidateSystemAccountName(group)
+		if err != nil {
+			return fmt.Errorf("User %s: %v", user.Name, err)
+		}
+	}
+	for _, p := range []string{user.Home, user.Shell} {
+		if p != "" && (!path.IsAbs(p) || unsafePathRegexp.MatchString(p)) {
+			return fmt.Errorf("User %s: invalid path '%s'", user.Name, p)
+		}
+	}
+	if unsafeGecosRegexp.MatchString(user.Gecos) {
+		return fmt.Errorf("User %s: invalid gecos '%s'", user.Name, user.Gecos)
+	}
+	return nil
+}
+
+// PrimaryGroup returns the user's primary group
+func (user *SystemUser) PrimaryGroup() string {
+	if user.Group == "" {
+		return user.Name
+	}
+	return user.Group
+}
+
+// HasHome checks whether the user has a real home directory
+func (user *SystemUser) HasHome() bool {
+	return user.Home != "" && user.Home != SystemUserHomeDefault
+}
+
+// ValidateSystemAccounts checks the package's system users and groups
+func ValidateSystemAccounts(pkg *Package) error {
+	for _, group := range pkg.SystemGroups {
+		err := ValidateSystemAccountName(group.Name)
+		if err != nil {
+			return err
+		}
+	}
+	for _, user := range pkg.SystemUsers {
+		err := user.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasSystemAccounts checks whether the package creates any system users or groups.
+// If so, debgen adds a dependency on adduser, which the maintainer scripts use to create them.
+func (pkg *Package) HasSystemAccounts() bool {
+	return len(pkg.SystemUsers) > 0 || len(pkg.SystemGroups) > 0
+}
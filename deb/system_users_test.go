@@ -0,0 +1,53 @@
+package deb_test
+
+import (
+	"github.com/laher/debgo-v0.2/deb"
+	"testing"
+)
+
+func TestSystemUserValidate(t *testing.T) {
+	user := deb.NewSystemUser("foo")
+	user.Groups = []string{"adm"}
+	user.Home = "/var/lib/foo"
+	if err := user.Validate(); err != nil {
+		t.Errorf("%v", err)
+	}
+	invalid := []*deb.SystemUser{
+		deb.NewSystemUser("Foo"),
+		deb.NewSystemUser("foo bar"),
+		{Name: "foo", Groups: []string{"a'b"}},
+		{Name: "foo", Home: "var/lib/foo"},
+		{Name: "foo", Shell: "/bin/sh'"},
+		{Name: "foo", Gecos: "a:b"},
+	}
+	for _, user := range invalid {
+		if err := user.Validate(); err == nil {
+			t.Errorf("User should be invalid: %+v", user)
+		}
+	}
+}
+
+func TestAddRelation(t *testing.T) {
+	tests := [][3]string{
+		{"", "adduser", "adduser"},
+		{"libc6", "adduser", "libc6, adduser"},
+		{"libc6, adduser (>= 3.11)", "adduser", "libc6, adduser (>= 3.11)"},
+		{"foo | adduser", "adduser", "foo | adduser"},
+	}
+	for _, test := range tests {
+		if actual := deb.AddRelation(test[0], test[1]); actual != test[2] {
+			t.Errorf("Expected '%s', got '%s'", test[2], actual)
+		}
+	}
+}
+
+func TestControlParagraphWithSystemAccounts(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me", "desc")
+	pkg.Depends = "libc6"
+	pkg.SystemUsers = []*deb.SystemUser{deb.NewSystemUser("testpkg")}
+	para := deb.NewDebWriter(pkg, deb.ArchAmd64).ControlParagraph()
+	// the dependency on adduser is added by debgen, along with the maintainer scripts
+	if depends, _ := para.Get("Depends"); depends != "libc6" {
+		t.Errorf("Expected Depends 'libc6', got '%s'", depends)
+	}
+}
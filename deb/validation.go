@@ -19,19 +19,20 @@ package deb
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 )
 
 // ValidateArchitecture checks the architecture string by parsing it.
 // Returns an error on failure.
-// Only supported architectures are considered valid. (e.g. armel is not supported, and non-linux OSes are also not supported yet)
+// Architectures (and wildcards) are checked against the table of known Debian architectures.
 //
 // See https://www.debian.org/doc/debian-policy/ch-controlfields.html#s-f-Architecture
 func ValidateArchitecture(archString string) error {
 	if archString == "source" { //OK
 		return nil
 	}
-	_, err := resolveArches(archString)
+	_, err := resolveArches(archString, KnownArchitectures(), nil)
 	return err
 }
 
@@ -70,19 +71,19 @@ func ParseVersion(packageVersion string) (string, string, string, error) {
 	var isEpoch bool
 	var isDebianRevision bool
 	if strings.Index(packageVersion, ":") > -1 && strings.Index(packageVersion, "-") > -1 {
-		validVersion = regexp.MustCompile(`^(.*):([0-9][a-zA-Z0-9+-.:~]+)-([a-zA-Z0-9+.~]+)$`)
+		validVersion = regexp.MustCompile(`^([0-9]+):([0-9][a-zA-Z0-9+.:~-]*)-([a-zA-Z0-9+.~]+)$`)
 		isEpoch = true
 		isDebianRevision = true
 	} else if strings.Index(packageVersion, ":") > -1 { //isEpoch
-		validVersion = regexp.MustCompile(`^(.*):([0-9][a-zA-Z0-9+.:~]+)$`)
+		validVersion = regexp.MustCompile(`^([0-9]+):([0-9][a-zA-Z0-9+.:~]*)$`)
 		isEpoch = true
 		isDebianRevision = false
 	} else if strings.Index(packageVersion, "-") > -1 { //revision
-		validVersion = regexp.MustCompile(`^([0-9][a-zA-Z0-9+-.~]+)-([a-zA-Z0-9+.~]+)$`)
+		validVersion = regexp.MustCompile(`^([0-9][a-zA-Z0-9+.~-]*)-([a-zA-Z0-9+.~]+)$`)
 		isEpoch = false
 		isDebianRevision = true
 	} else { // neither
-		validVersion = regexp.MustCompile(`^([0-9][a-zA-Z0-9+.~]+)$`)
+		validVersion = regexp.MustCompile(`^([0-9][a-zA-Z0-9+.~]*)$`)
 		isEpoch = false
 		isDebianRevision = false
 	}
@@ -125,5 +126,21 @@ func ValidatePackage(pkg *Package) error {
 	if pkg.Maintainer == "" {
 		return fmt.Errorf("Maintainer property is required")
 	}
+	err = ValidateSystemAccounts(pkg)
+	if err != nil {
+		return err
+	}
+	// every relationship field is checked, whether it's stored in a member or in AdditionalControlData
+	fields := []string{}
+	for field := range relationFieldRules {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		err = ValidateRelations(field, pkg.GetField(field))
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -23,8 +23,8 @@ import (
 )
 
 var (
-	validVersions = []string{"1.2.3a", "123-x"}
-	badVersions   = []string{"12!3", "a123-x"}
+	validVersions = []string{"1.2.3a", "123-x", "1", "1:1.0-1", "1.0-1-1"}
+	badVersions   = []string{"12!3", "a123-x", "a:1.0", "1.0-"}
 )
 
 func ExampleValidateVersion() {
@@ -0,0 +1,395 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed debian version: [epoch:]upstream_version[-debian_revision]
+//
+// Versions are ordered in the same way as `dpkg --compare-versions`.
+//
+// See http://www.debian.org/doc/debian-policy/ch-controlfields.html#s-f-Version
+type Version struct {
+	Epoch    int    // Epoch. 0 when not specified
+	Upstream string // Upstream version
+	Revision string // Debian revision. Empty for native packages
+}
+
+// NewVersion parses a version string (see ParseVersion) into a Version.
+func NewVersion(version string) (*Version, error) {
+	epoch, upstream, revision, err := ParseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	v := &Version{Upstream: upstream, Revision: revision}
+	if epoch != "" {
+		v.Epoch, err = strconv.Atoi(epoch)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid epoch '%s' in version '%s'", epoch, version)
+		}
+	}
+	return v, nil
+}
+
+// String formats the version as it would appear in a control file.
+// The epoch is omitted when it is 0.
+func (v *Version) String() string {
+	s := v.Upstream
+	if v.Epoch != 0 {
+		s = strconv.Itoa(v.Epoch) + ":" + s
+	}
+	if v.Revision != "" {
+		s = s + "-" + v.Revision
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 when v is respectively older than, equal to, or newer than other.
+//
+// The epochs are compared numerically, then the upstream versions and debian revisions are compared using dpkg's algorithm.
+func (v *Version) Compare(other *Version) int {
+	if v.Epoch != other.Epoch {
+		if v.Epoch < other.Epoch {
+			return -1
+		}
+		return 1
+	}
+	ret := compareVersionPart(v.Upstream, other.Upstream)
+	if ret == 0 {
+		ret = compareVersionPart(v.Revision, other.Revision)
+	}
+	return ret
+}
+
+// LessThan is equivalent to the '<<' relation
+func (v *Version) LessThan(other *Version) bool {
+	return v.Compare(other) < 0
+}
+
+// LessThanOrEqual is equivalent to the '<=' relation
+func (v *Version) LessThanOrEqual(other *Version) bool {
+	return v.Compare(other) <= 0
+}
+
+// Equal is equivalent to the '=' relation
+func (v *Version) Equal(other *Version) bool {
+	return v.Compare(other) == 0
+}
+
+// GreaterThanOrEqual is equivalent to the '>=' relation
+func (v *Version) GreaterThanOrEqual(other *Version) bool {
+	return v.Compare(other) >= 0
+}
+
+// GreaterThan is equivalent to the '>>' relation
+func (v *Version) GreaterThan(other *Version) bool {
+	return v.Compare(other) > 0
+}
+
+// Satisfies checks the relation 'v op other'.
+// op may be one of the relation operators used in control files (<<, <=, =, >=, >>),
+// or one of the equivalent `dpkg --compare-versions` operators (lt, le, eq, ne, ge, gt).
+func (v *Version) Satisfies(op string, other *Version) (bool, error) {
+	ret := v.Compare(other)
+	switch op {
+	case "<<", "lt":
+		return ret < 0, nil
+	case "<=", "le":
+		return ret <= 0, nil
+	case "=", "eq":
+		return ret == 0, nil
+	case "ne":
+		return ret != 0, nil
+	case ">=", "ge":
+		return ret >= 0, nil
+	case ">>", "gt":
+		return ret > 0, nil
+	}
+	return false, fmt.Errorf("Invalid version relation '%s'", op)
+}
+
+// CompareVersions is the equivalent of `dpkg --compare-versions a op b`.
+// Returns an error if either version is invalid, or if op is not recognised.
+func CompareVersions(a, op, b string) (bool, error) {
+	va, err := NewVersion(a)
+	if err != nil {
+		return false, err
+	}
+	vb, err := NewVersion(b)
+	if err != nil {
+		return false, err
+	}
+	return va.Satisfies(op, vb)
+}
+
+// versionOrder gives the weight of a non-digit character.
+// Letters sort earlier than non-letters, and '~' sorts before anything, even the end of the string.
+func versionOrder(c byte) int {
+	switch {
+	case c == 0 || isDigit(c):
+		return 0
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return int(c)
+	case c == '~':
+		return -1
+	}
+	return int(c) + 256
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// compareVersionPart compares upstream versions or debian revisions.
+// It walks both strings as alternating non-digit and digit segments, as dpkg's verrevcmp does.
+func compareVersionPart(a, b string) int {
+	at := func(s string, i int) byte {
+		if i < len(s) {
+			return s[i]
+		}
+		return 0
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		firstDiff := 0
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac := versionOrder(at(a, i))
+			bc := versionOrder(at(b, j))
+			if ac != bc {
+				return sign(ac - bc)
+			}
+			i++
+			j++
+		}
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		for i < len(a) && j < len(b) && isDigit(a[i]) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return sign(firstDiff)
+		}
+	}
+	return 0
+}
+
+func sign(i int) int {
+	if i < 0 {
+		return -1
+	} else if i > 0 {
+		return 1
+	}
+	return 0
+}
+
+var (
+	trailingNumberRegexp = regexp.MustCompile(`^(.*?)([0-9]+)$`)
+	nmuRevisionRegexp    = regexp.MustCompile(`^([0-9]+)\.([0-9]+)$`)
+	nmuNativeRegexp      = regexp.MustCompile(`^(.*)\+nmu([0-9]+)$`)
+	backportRegexp       = regexp.MustCompile(`^(.*)~bpo([0-9]+)\+([0-9]+)$`)
+	ppaRegexp            = regexp.MustCompile(`^(.*)~ppa([0-9]+)$`)
+	preReleaseRegexp     = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9+.]*$`)
+)
+
+// Version bumps, as accepted by BumpVersion.
+const (
+	VersionBumpRevision   = "revision"   // See Version.NextRevision
+	VersionBumpNMU        = "nmu"        // See Version.NMU
+	VersionBumpBackport   = "backport"   // See Version.Backport. Requires the release number, e.g. 'backport:12'
+	VersionBumpPPA        = "ppa"        // See Version.PPA
+	VersionBumpPreRelease = "prerelease" // See Version.PreRelease. Requires the marker, e.g. 'prerelease:rc1'
+)
+
+// NextRevision increments the debian revision, e.g. 1.2-1 becomes 1.2-2, and 1.2-0ubuntu1 becomes 1.2-0ubuntu2.
+// NMU revisions are superseded by the maintainer's next revision, e.g. 1.2-1.1 becomes 1.2-2.
+// The result is newer than v.
+// Native versions have no revision to increment, so an error is returned.
+func (v *Version) NextRevision() (*Version, error) {
+	if v.Revision == "" {
+		return nil, fmt.Errorf("Version '%s' has no debian revision", v)
+	}
+	next := *v
+	if matches := nmuRevisionRegexp.FindStringSubmatch(v.Revision); matches != nil {
+		next.Revision = incrementTrailingNumber(matches[1])
+	} else {
+		next.Revision = incrementTrailingNumber(v.Revision)
+	}
+	return checkOrder(v, &next, 1)
+}
+
+// NMU creates a Non-Maintainer Upload version, e.g. 1.2-1 becomes 1.2-1.1, and 1.2-1.1 becomes 1.2-1.2.
+// Native versions get a '+nmu' suffix, e.g. 1.2 becomes 1.2+nmu1.
+// The result is newer than v.
+//
+// See https://www.debian.org/doc/manuals/developers-reference/pkgs.html#nmu-version
+func (v *Version) NMU() (*Version, error) {
+	next := *v
+	if v.Revision == "" {
+		if nmuNativeRegexp.MatchString(v.Upstream) {
+			next.Upstream = incrementTrailingNumber(v.Upstream)
+		} else {
+			next.Upstream = v.Upstream + "+nmu1"
+		}
+	} else if nmuRevisionRegexp.MatchString(v.Revision) {
+		next.Revision = incrementTrailingNumber(v.Revision)
+	} else {
+		next.Revision = v.Revision + ".1"
+	}
+	return checkOrder(v, &next, 1)
+}
+
+// Backport creates a backport version for the given Debian release, e.g. 1.2-1 becomes 1.2-1~bpo12+1 for release 12.
+// When v is already a backport for that release, the backport number is incremented instead (1.2-1~bpo12+2).
+// A new backport is older than v, so that upgrading to the release's own version works.
+//
+// See https://backports.debian.org/Contribute/#index6h3
+func (v *Version) Backport(release int) (*Version, error) {
+	if release < 1 {
+		return nil, fmt.Errorf("Invalid release number %d", release)
+	}
+	next := *v
+	part := v.lastPart()
+	if matches := backportRegexp.FindStringSubmatch(*part); matches != nil && matches[2] == strconv.Itoa(release) {
+		*next.lastPart() = incrementTrailingNumber(*part)
+		return checkOrder(v, &next, 1)
+	}
+	*next.lastPart() = fmt.Sprintf("%s~bpo%d+1", *part, release)
+	return checkOrder(v, &next, -1)
+}
+
+// PPA creates a version for a personal package archive, e.g. 1.2-1 becomes 1.2-1~ppa1.
+// When v is already a PPA version, the PPA number is incremented instead (1.2-1~ppa2).
+// A new PPA version is older than v, so that upgrading to the official version works.
+func (v *Version) PPA() (*Version, error) {
+	next := *v
+	part := v.lastPart()
+	if ppaRegexp.MatchString(*part) {
+		*next.lastPart() = incrementTrailingNumber(*part)
+		return checkOrder(v, &next, 1)
+	}
+	*next.lastPart() = *part + "~ppa1"
+	return checkOrder(v, &next, -1)
+}
+
+// PreRelease marks the upstream version as a pre-release, e.g. 1.2.0-1 becomes 1.2.0~rc1-1 for marker 'rc1'.
+// The result is older than v, so that the final release supersedes it.
+// When v is already a pre-release, its marker is replaced instead, e.g. 1.2.0~rc1-1 becomes 1.2.0~rc2-1 for marker 'rc2'.
+// The new marker must then sort after the old one.
+func (v *Version) PreRelease(marker string) (*Version, error) {
+	if !preReleaseRegexp.MatchString(marker) {
+		return nil, fmt.Errorf("Invalid pre-release marker '%s'", marker)
+	}
+	next := *v
+	if i := strings.LastIndex(v.Upstream, "~"); i > -1 {
+		next.Upstream = v.Upstream[:i] + "~" + marker
+		return checkOrder(v, &next, 1)
+	}
+	next.Upstream = v.Upstream + "~" + marker
+	return checkOrder(v, &next, -1)
+}
+
+// BumpVersion applies a version bump to a version string.
+// bump is one of the VersionBump constants, followed by ':' and an argument where required.
+// e.g. 'revision', 'nmu', 'backport:12', 'ppa', 'prerelease:rc1'
+func BumpVersion(version, bump string) (string, error) {
+	v, err := NewVersion(version)
+	if err != nil {
+		return "", err
+	}
+	kind := bump
+	arg := ""
+	if i := strings.Index(bump, ":"); i > -1 {
+		kind = bump[:i]
+		arg = bump[i+1:]
+	}
+	var next *Version
+	switch kind {
+	case VersionBumpRevision:
+		next, err = v.NextRevision()
+	case VersionBumpNMU:
+		next, err = v.NMU()
+	case VersionBumpBackport:
+		var release int
+		release, err = strconv.Atoi(arg)
+		if err != nil {
+			return "", fmt.Errorf("Backport requires a release number, e.g. '%s:12'", VersionBumpBackport)
+		}
+		next, err = v.Backport(release)
+	case VersionBumpPPA:
+		next, err = v.PPA()
+	case VersionBumpPreRelease:
+		next, err = v.PreRelease(arg)
+	default:
+		return "", fmt.Errorf("Unknown version bump '%s'", bump)
+	}
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
+
+// lastPart returns the revision, or the upstream version for native versions.
+func (v *Version) lastPart() *string {
+	if v.Revision == "" {
+		return &v.Upstream
+	}
+	return &v.Revision
+}
+
+// incrementTrailingNumber increments the number at the end of s, or appends '1' if there isn't one.
+func incrementTrailingNumber(s string) string {
+	matches := trailingNumberRegexp.FindStringSubmatch(s)
+	if matches == nil {
+		return s + "1"
+	}
+	n, err := strconv.Atoi(matches[2])
+	if err != nil {
+		// too long for an int. Extend it instead
+		return s + ".1"
+	}
+	return matches[1] + strconv.Itoa(n+1)
+}
+
+// checkOrder checks that next is valid, and compares to v as expected (1 for newer, -1 for older).
+func checkOrder(v, next *Version, expected int) (*Version, error) {
+	if err := ValidateVersion(next.String()); err != nil {
+		return nil, err
+	}
+	if next.Compare(v) != expected {
+		return nil, fmt.Errorf("Version '%s' does not sort as expected relative to '%s'", next, v)
+	}
+	return next, nil
+}
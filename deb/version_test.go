@@ -0,0 +1,239 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package deb_test
+
+import (
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"log"
+	"testing"
+)
+
+// Comparison cases, mostly taken from dpkg's own test suite (lib/dpkg/t/t-version.c and t/dpkg_compare-versions)
+var versionComparisons = []struct {
+	a        string
+	b        string
+	expected int
+}{
+	// epochs
+	{"0:1.0", "1.0", 0},
+	{"1:1.0", "1.0", 1},
+	{"1:0.1", "9.9", 1},
+	{"2:1.0", "10:0.1", -1},
+	{"0:0-0", "0:0-0", 0},
+	{"0:0.0-0", "0:0-0", 1},
+	{"0:0-00", "0:0-0", 0},
+	{"0:0-00", "0:0-00", 0},
+	{"1:2-3", "1:2-3", 0},
+	{"1:1.0-1", "1.0-1", 1},
+
+	// upstream versions
+	{"1.0", "1.0", 0},
+	{"1.0", "1.1", -1},
+	{"1.1", "1.0", 1},
+	{"1.2.3", "1.2.10", -1},
+	{"1.01", "1.1", 0},
+	{"1.001", "1.1", 0},
+	{"1.0.0", "1.0", 1},
+	{"1.0", "1.0.", -1},
+	{"1.0a", "1.0", 1},
+	{"1.0a", "1.0b", -1},
+	{"1.0A", "1.0a", -1},
+	{"1.0+", "1.0", 1},
+	{"1.0+dfsg", "1.0", 1},
+	{"1.0+dfsg", "1.0.1", -1},
+	{"1.0+", "1.0a", 1},
+	{"2.4.7", "2.4.7+git", -1},
+	{"0", "00", 0},
+	{"1", "2", -1},
+	{"10", "9", 1},
+
+	// tilde sorts before everything
+	{"1.0~rc1", "1.0", -1},
+	{"1.0~rc1", "1.0~rc2", -1},
+	{"1.0~rc1", "1.0~beta1", 1},
+	{"1.0~~", "1.0~", -1},
+	{"1.0~~a", "1.0~~", 1},
+	{"1.0~", "1.0", -1},
+	{"1.0~", "1.0a", -1},
+	{"0~", "0", -1},
+	{"1.2.3~", "1.2.3", -1},
+
+	// debian revisions
+	{"1.0-1", "1.0-2", -1},
+	{"1.0-1", "1.0", 1},
+	{"1.0-0", "1.0", 0},
+	{"1.0-1ubuntu1", "1.0-1", 1},
+	{"1.0-1~bpo12+1", "1.0-1", -1},
+	{"1.0-1.1", "1.0-1", 1},
+	{"1.0-1.1", "1.0-2", -1},
+	{"1.0-abc", "1.0-abd", -1},
+	{"1.0-1-1", "1.0-1", 1},
+	{"1.0-1+b1", "1.0-1", 1},
+	{"2.30-9", "2.30-10", -1},
+	{"1.0-10", "1.0-9", 1},
+}
+
+func ExampleCompareVersions() {
+	newer, err := deb.CompareVersions("1.0-2", ">>", "1.0~rc1-1")
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Println(newer)
+
+	// Output:
+	// true
+}
+
+func TestVersionCompare(t *testing.T) {
+	for _, c := range versionComparisons {
+		a, err := deb.NewVersion(c.a)
+		if err != nil {
+			t.Fatalf("Error parsing %s: %v", c.a, err)
+		}
+		b, err := deb.NewVersion(c.b)
+		if err != nil {
+			t.Fatalf("Error parsing %s: %v", c.b, err)
+		}
+		if ret := a.Compare(b); ret != c.expected {
+			t.Errorf("Compare(%s, %s): expected %d, got %d", c.a, c.b, c.expected, ret)
+		}
+		// Comparison should be antisymmetric
+		if ret := b.Compare(a); ret != -c.expected {
+			t.Errorf("Compare(%s, %s): expected %d, got %d", c.b, c.a, -c.expected, ret)
+		}
+	}
+}
+
+func TestVersionRelations(t *testing.T) {
+	for _, c := range versionComparisons {
+		a, _ := deb.NewVersion(c.a)
+		b, _ := deb.NewVersion(c.b)
+		expected := map[string]bool{
+			"<<": c.expected < 0,
+			"<=": c.expected <= 0,
+			"=":  c.expected == 0,
+			">=": c.expected >= 0,
+			">>": c.expected > 0,
+		}
+		actual := map[string]bool{
+			"<<": a.LessThan(b),
+			"<=": a.LessThanOrEqual(b),
+			"=":  a.Equal(b),
+			">=": a.GreaterThanOrEqual(b),
+			">>": a.GreaterThan(b),
+		}
+		for op, exp := range expected {
+			if actual[op] != exp {
+				t.Errorf("%s %s %s: expected %v", c.a, op, c.b, exp)
+			}
+			res, err := deb.CompareVersions(c.a, op, c.b)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if res != exp {
+				t.Errorf("CompareVersions(%s %s %s): expected %v", c.a, op, c.b, exp)
+			}
+		}
+	}
+	_, err := deb.CompareVersions("1.0", "<", "1.1")
+	if err == nil {
+		t.Errorf("Obsolete relation '<' should not be accepted")
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	for _, s := range []string{"1.0", "1:1.0", "1.0-1", "2:1.0~rc1-0ubuntu1"} {
+		v, err := deb.NewVersion(s)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if v.String() != s {
+			t.Errorf("Expected %s, got %s", s, v.String())
+		}
+	}
+	v, _ := deb.NewVersion("0:1.0")
+	if v.String() != "1.0" {
+		t.Errorf("Zero epoch should be omitted. Got %s", v.String())
+	}
+}
+
+func ExampleBumpVersion() {
+	for _, bump := range []string{"revision", "nmu", "backport:12", "ppa", "prerelease:rc1"} {
+		next, err := deb.BumpVersion("1.2.0-1", bump)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Printf("%s: %s\n", bump, next)
+	}
+
+	// Output:
+	// revision: 1.2.0-2
+	// nmu: 1.2.0-1.1
+	// backport:12: 1.2.0-1~bpo12+1
+	// ppa: 1.2.0-1~ppa1
+	// prerelease:rc1: 1.2.0~rc1-1
+}
+
+func TestBumpVersion(t *testing.T) {
+	bumps := []struct {
+		version  string
+		bump     string
+		expected string
+	}{
+		{"1.0-9", "revision", "1.0-10"},
+		{"1:1.0-0ubuntu1", "revision", "1:1.0-0ubuntu2"},
+		{"1.0-1a", "revision", "1.0-1a1"},
+		{"1.0-1.1", "nmu", "1.0-1.2"},
+		{"1.0-0ubuntu1", "nmu", "1.0-0ubuntu1.1"},
+		{"1.0", "nmu", "1.0+nmu1"},
+		{"1.0+nmu1", "nmu", "1.0+nmu2"},
+		{"1.0-1~bpo12+1", "backport:12", "1.0-1~bpo12+2"},
+		{"1.0-1~bpo11+1", "backport:12", "1.0-1~bpo11+1~bpo12+1"},
+		{"1.0", "backport:12", "1.0~bpo12+1"},
+		{"1.0-1~ppa1", "ppa", "1.0-1~ppa2"},
+		{"1.0", "ppa", "1.0~ppa1"},
+		{"1.0", "prerelease:beta.2", "1.0~beta.2"},
+		{"1.2.0~rc1-1", "prerelease:rc2", "1.2.0~rc2-1"},
+		{"1.2.0~beta1", "prerelease:rc1", "1.2.0~rc1"},
+		{"1.2-1.1", "revision", "1.2-2"},
+	}
+	// successive pre-releases sort upwards
+	rc2, err := deb.BumpVersion("1.2.0~rc1-1", "prerelease:rc2")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if newer, _ := deb.CompareVersions(rc2, ">>", "1.2.0~rc1-1"); !newer {
+		t.Errorf("%s should sort after 1.2.0~rc1-1", rc2)
+	}
+	for _, b := range bumps {
+		next, err := deb.BumpVersion(b.version, b.bump)
+		if err != nil {
+			t.Errorf("%s %s: %v", b.version, b.bump, err)
+			continue
+		}
+		if next != b.expected {
+			t.Errorf("%s %s: expected %s, got %s", b.version, b.bump, b.expected, next)
+		}
+	}
+	for _, bad := range [][]string{{"1.0", "revision"}, {"1.0-1", "backport"}, {"1.0-1", "prerelease:~rc1"}, {"1.0~rc2", "prerelease:rc1"}, {"1.0-1", "foo"}} {
+		_, err := deb.BumpVersion(bad[0], bad[1])
+		if err == nil {
+			t.Errorf("%s %s: expected an error", bad[0], bad[1])
+		}
+	}
+}
@@ -0,0 +1,185 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package debgen
+
+import (
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"path"
+	"strings"
+)
+
+// Alternative is a link managed by update-alternatives (see DebGenerator.Alternatives)
+type Alternative struct {
+	Link     string              // Generic name, e.g. '/usr/bin/go'
+	Name     string              // Name of the link group, e.g. 'go'
+	Path     string              // The alternative, installed by the package, e.g. '/usr/lib/foo/bin/go'
+	Priority int                 // Higher priorities are preferred in automatic mode
+	Slaves   []*AlternativeSlave // Links which follow this one, e.g. man pages
+}
+
+// AlternativeSlave is a link which follows its master Alternative
+type AlternativeSlave struct {
+	Link string // e.g. '/usr/share/man/man1/go.1.gz'
+	Name string // e.g. 'go.1.gz'
+	Path string // e.g. '/usr/lib/foo/man/go.1.gz'
+}
+
+// Diversion tells dpkg to install another package's file elsewhere, using dpkg-divert (see DebGenerator.Diversions)
+type Diversion struct {
+	Path     string // The file to divert, e.g. '/usr/bin/go'
+	DivertTo string // Where the other package's file is installed. Default: Path + '.distrib'
+	IsRename bool   // Whether to move an already-installed file. Default true
+}
+
+// NewAlternative is a factory for Alternative, named after the link
+func NewAlternative(link, altPath string, priority int) *Alternative {
+	return &Alternative{Link: link, Name: path.Base(link), Path: altPath, Priority: priority, Slaves: []*AlternativeSlave{}}
+}
+
+// NewDiversion is a factory for Diversion, diverting to Path + '.distrib' and renaming any installed file
+func NewDiversion(divertedPath string) *Diversion {
+	return &Diversion{Path: divertedPath, DivertTo: divertedPath + ".distrib", IsRename: true}
+}
+
+// AddSlave adds a slave link, named after the link
+func (alt *Alternative) AddSlave(link, slavePath string) {
+	alt.Slaves = append(alt.Slaves, &AlternativeSlave{Link: link, Name: path.Base(link), Path: slavePath})
+}
+
+func validateDeclaredPath(kind, p string) error {
+	if !path.IsAbs(p) || strings.ContainsAny(p, "'\" \t\n") {
+		return fmt.Errorf("Invalid %s '%s'", kind, p)
+	}
+	return nil
+}
+
+func validateAlternativeName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/'\" \t\n") {
+		return fmt.Errorf("Invalid alternative name '%s'", name)
+	}
+	return nil
+}
+
+// ValidateAlternatives checks the alternatives' links, names and paths.
+// dataFiles are the data archive's files (see ValidateConffiles). Each alternative's paths must be among them, and its links must not be.
+func ValidateAlternatives(alts []*Alternative, dataFiles []string) error {
+	files := dataFileSet(dataFiles)
+	names := map[string]bool{}
+	for _, alt := range alts {
+		err := validateAlternativeName(alt.Name)
+		if err != nil {
+			return err
+		}
+		if names[alt.Name] {
+			return fmt.Errorf("Duplicate alternative '%s'", alt.Name)
+		}
+		names[alt.Name] = true
+		links := []*AlternativeSlave{{Link: alt.Link, Name: alt.Name, Path: alt.Path}}
+		links = append(links, alt.Slaves...)
+		for _, link := range links {
+			if err = validateAlternativeName(link.Name); err != nil {
+				return err
+			}
+			if err = validateDeclaredPath("alternative link", link.Link); err != nil {
+				return err
+			}
+			if err = validateDeclaredPath("alternative path", link.Path); err != nil {
+				return err
+			}
+			if !files[deb.ConffilePath(link.Path)] {
+				return fmt.Errorf("Alternative %s: '%s' is not in the data archive", link.Name, link.Path)
+			}
+			if files[deb.ConffilePath(link.Link)] {
+				return fmt.Errorf("Alternative %s: link '%s' should not be in the data archive", link.Name, link.Link)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateDiversions checks the diversions' paths. The package must ship its own file at each diverted path, and must not ship the file it is diverted to.
+func ValidateDiversions(divs []*Diversion, dataFiles []string) error {
+	files := dataFileSet(dataFiles)
+	for _, div := range divs {
+		err := validateDeclaredPath("diversion", div.Path)
+		if err != nil {
+			return err
+		}
+		err = validateDeclaredPath("diversion", divertTo(div))
+		if err != nil {
+			return err
+		}
+		if !files[deb.ConffilePath(div.Path)] {
+			return fmt.Errorf("Diversion of %s: the diverted path is not in the data archive", div.Path)
+		}
+		if files[deb.ConffilePath(divertTo(div))] {
+			return fmt.Errorf("Diversion of %s: '%s' should not be in the data archive", div.Path, divertTo(div))
+		}
+	}
+	return nil
+}
+
+func dataFileSet(dataFiles []string) map[string]bool {
+	files := map[string]bool{}
+	for _, dataFile := range dataFiles {
+		files[deb.ConffilePath(dataFile)] = true
+	}
+	return files
+}
+
+func divertTo(div *Diversion) string {
+	if div.DivertTo == "" {
+		return div.Path + ".distrib"
+	}
+	return div.DivertTo
+}
+
+// AlternativesSnippets returns maintainer script snippets which install the alternatives on configuration, and remove them on removal.
+func AlternativesSnippets(alts []*Alternative) []*ScriptSnippet {
+	snippets := []*ScriptSnippet{}
+	for _, alt := range alts {
+		install := fmt.Sprintf("update-alternatives --install '%s' '%s' '%s' %d", alt.Link, alt.Name, alt.Path, alt.Priority)
+		for _, slave := range alt.Slaves {
+			install += fmt.Sprintf(" \\\n\t--slave '%s' '%s' '%s'", slave.Link, slave.Name, slave.Path)
+		}
+		snippets = append(snippets,
+			&ScriptSnippet{Script: "postinst", Actions: []string{"configure"}, Provider: "debgo alternatives", Code: install},
+			&ScriptSnippet{Script: "prerm", Actions: []string{"remove", "deconfigure"}, Provider: "debgo alternatives",
+				Code: fmt.Sprintf("update-alternatives --remove '%s' '%s'", alt.Name, alt.Path)},
+		)
+	}
+	return snippets
+}
+
+// DiversionsSnippets returns maintainer script snippets which add the diversions before installation, and remove them after removal.
+func DiversionsSnippets(packageName string, divs []*Diversion) []*ScriptSnippet {
+	snippets := []*ScriptSnippet{}
+	for _, div := range divs {
+		rename := "--rename"
+		if !div.IsRename {
+			rename = "--no-rename"
+		}
+		snippets = append(snippets,
+			&ScriptSnippet{Script: "preinst", Actions: []string{"install", "upgrade"}, Provider: "debgo diversions",
+				Code: fmt.Sprintf("dpkg-divert --package '%s' --add %s --divert '%s' '%s'", packageName, rename, divertTo(div), div.Path)},
+			&ScriptSnippet{Script: "postrm", Actions: []string{"remove", "abort-install", "disappear"}, Provider: "debgo diversions",
+				Code: fmt.Sprintf("dpkg-divert --package '%s' --remove %s --divert '%s' '%s'", packageName, rename, divertTo(div), div.Path)},
+		)
+	}
+	return snippets
+}
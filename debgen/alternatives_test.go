@@ -0,0 +1,88 @@
+package debgen_test
+
+import (
+	"bytes"
+	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/debgen"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenAlternativesAndDiversions(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "debgen-alternatives")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	exe := filepath.Join(tmpDir, "go")
+	if err = ioutil.WriteFile(exe, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	build.TmpDir = tmpDir
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+	dgen.OrigFiles["/usr/lib/testpkg/bin/go"] = exe
+	dgen.OrigFiles["/usr/lib/testpkg/go.1.gz"] = exe
+	dgen.OrigFiles["/usr/bin/gofmt"] = exe
+	alt := debgen.NewAlternative("/usr/bin/go", "/usr/lib/testpkg/bin/go", 50)
+	alt.AddSlave("/usr/share/man/man1/go.1.gz", "/usr/lib/testpkg/go.1.gz")
+	dgen.Alternatives = []*debgen.Alternative{alt}
+	dgen.Diversions = []*debgen.Diversion{debgen.NewDiversion("/usr/bin/gofmt")}
+	buf := new(bytes.Buffer)
+	if err = dgen.GenerateTo(buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	scripts := map[string][]string{
+		"postinst": {"if [ \"$1\" = \"configure\" ]; then", "update-alternatives --install '/usr/bin/go' 'go' '/usr/lib/testpkg/bin/go' 50 \\\n\t\t--slave '/usr/share/man/man1/go.1.gz' 'go.1.gz' '/usr/lib/testpkg/go.1.gz'"},
+		"prerm":    {"update-alternatives --remove 'go' '/usr/lib/testpkg/bin/go'"},
+		"preinst":  {"dpkg-divert --package 'testpkg' --add --rename --divert '/usr/bin/gofmt.distrib' '/usr/bin/gofmt'"},
+		"postrm":   {"dpkg-divert --package 'testpkg' --remove --rename --divert '/usr/bin/gofmt.distrib' '/usr/bin/gofmt'"},
+	}
+	for name, fragments := range scripts {
+		script := new(bytes.Buffer)
+		err = deb.DebExtractFileL2(bytes.NewReader(buf.Bytes()), deb.BinaryControlArchiveNameDefault, name, script)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, fragment := range fragments {
+			if !strings.Contains(script.String(), fragment) {
+				t.Errorf("%s should contain '%s', got:\n%s", name, fragment, script.String())
+			}
+		}
+	}
+}
+
+func TestValidateAlternatives(t *testing.T) {
+	dataFiles := []string{"./usr/lib/foo/go", "./usr/bin/go"}
+	alt := debgen.NewAlternative("/usr/bin/gofmt", "/usr/lib/foo/go", 10)
+	if err := debgen.ValidateAlternatives([]*debgen.Alternative{alt}, dataFiles); err != nil {
+		t.Errorf("Valid alternative: %v", err)
+	}
+	missing := debgen.NewAlternative("/usr/bin/gofmt", "/usr/lib/foo/gofmt", 10)
+	shipped := debgen.NewAlternative("/usr/bin/go", "/usr/lib/foo/go", 10)
+	badSlave := debgen.NewAlternative("/usr/bin/gofmt", "/usr/lib/foo/go", 10)
+	badSlave.AddSlave("/usr/share/man/man1/gofmt.1.gz", "/usr/lib/foo/gofmt.1.gz")
+	for _, bad := range []*debgen.Alternative{missing, shipped, badSlave, debgen.NewAlternative("usr/bin/gofmt", "/usr/lib/foo/go", 10)} {
+		if err := debgen.ValidateAlternatives([]*debgen.Alternative{bad}, dataFiles); err == nil {
+			t.Errorf("Alternative %s -> %s should be invalid", bad.Link, bad.Path)
+		}
+	}
+	if err := debgen.ValidateAlternatives([]*debgen.Alternative{alt, alt}, dataFiles); err == nil {
+		t.Errorf("Duplicate alternatives should be invalid")
+	}
+	div := debgen.NewDiversion("/usr/bin/go")
+	div.DivertTo = "/usr/lib/foo/go"
+	if err := debgen.ValidateDiversions([]*debgen.Diversion{div}, dataFiles); err == nil {
+		t.Errorf("Diverting onto a packaged file should be invalid")
+	}
+	if err := debgen.ValidateDiversions([]*debgen.Diversion{debgen.NewDiversion("/usr/bin/go")}, dataFiles); err != nil {
+		t.Errorf("Valid diversion: %v", err)
+	}
+	if err := debgen.ValidateDiversions([]*debgen.Diversion{debgen.NewDiversion("/usr/bin/gofmt")}, dataFiles); err == nil {
+		t.Errorf("Diverting a file which isn't packaged should be invalid")
+	}
+}
@@ -0,0 +1,68 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package debgen
+
+import (
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// NewDebWriters creates a DebWriter for each architecture the package should be built for.
+//
+// Concrete architectures are used as-is.
+// 'any' and other wildcards resolve to BuildParams.Arches, if set.
+// Otherwise they resolve to the 'available' architectures (i.e. those for which binaries exist).
+func NewDebWriters(pkg *deb.Package, build *BuildParams, available []deb.Architecture) (map[deb.Architecture]*deb.DebWriter, error) {
+	candidates := build.Arches
+	if len(candidates) == 0 {
+		candidates = available
+	}
+	writers, err := deb.NewDebWritersForArches(pkg, candidates)
+	if err != nil {
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("No architectures available for '%s'. Specify the architectures to build, or provide binaries: %v", pkg.Architecture, err)
+		}
+		return nil, err
+	}
+	return writers, nil
+}
+
+// ArchesWithBinaries finds the architectures for which binaries exist.
+// binDir should contain a subdirectory for each architecture (e.g. 'amd64'), containing at least one file.
+func ArchesWithBinaries(binDir string) ([]deb.Architecture, error) {
+	arches := []deb.Architecture{}
+	for _, arch := range deb.KnownArchitectures() {
+		archBinDir := filepath.Join(binDir, string(arch))
+		fis, err := ioutil.ReadDir(archBinDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, fi := range fis {
+			if !fi.IsDir() {
+				arches = append(arches, arch)
+				break
+			}
+		}
+	}
+	return arches, nil
+}
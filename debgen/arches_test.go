@@ -0,0 +1,80 @@
+package debgen_test
+
+import (
+	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/debgen"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestArchesWithBinaries(t *testing.T) {
+	binDir, err := ioutil.TempDir("", "debgen-arches")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(binDir)
+	for _, exe := range []string{"amd64/a", "armhf/a", "notanarch/a"} {
+		exe = filepath.Join(binDir, exe)
+		if err = os.MkdirAll(filepath.Dir(exe), 0777); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err = ioutil.WriteFile(exe, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	// empty directories don't count
+	if err = os.MkdirAll(filepath.Join(binDir, "i386"), 0777); err != nil {
+		t.Fatalf("%v", err)
+	}
+	arches, err := debgen.ArchesWithBinaries(binDir)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := []deb.Architecture{deb.ArchAmd64, deb.ArchArmhf}
+	if !reflect.DeepEqual(arches, expected) {
+		t.Errorf("Expected %v, got %v", expected, arches)
+	}
+}
+
+func TestNewDebWriters(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	available := []deb.Architecture{deb.ArchAmd64, deb.ArchArmhf}
+
+	writers, err := debgen.NewDebWriters(pkg, build, available)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(writers) != 2 || writers[deb.ArchAmd64] == nil || writers[deb.ArchArmhf] == nil {
+		t.Errorf("Expected writers for available arches, got %v", writers)
+	}
+
+	build.Arches = []deb.Architecture{deb.ArchArm64, deb.ArchI386}
+	writers, err = debgen.NewDebWriters(pkg, build, available)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(writers) != 2 || writers[deb.ArchArm64] == nil || writers[deb.ArchI386] == nil {
+		t.Errorf("Expected writers for configured arches, got %v", writers)
+	}
+
+	// concrete architectures aren't affected by the fan-out
+	pkg.Architecture = "all"
+	writers, err = debgen.NewDebWriters(pkg, build, available)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(writers) != 1 || writers[deb.ArchAll] == nil {
+		t.Errorf("Expected a single writer for 'all', got %v", writers)
+	}
+
+	pkg.Architecture = "any"
+	build.Arches = nil
+	_, err = debgen.NewDebWriters(pkg, build, nil)
+	if err == nil {
+		t.Errorf("Expected an error when no architectures are available")
+	}
+}
@@ -1,11 +1,20 @@
 package debgen_test
 
 import (
+	"bytes"
+	"github.com/laher/argo/ar"
 	"github.com/laher/debgo-v0.2/deb"
 	"github.com/laher/debgo-v0.2/debgen"
+	"github.com/laher/debgo-v0.2/targz"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
 )
 
 func Example_genBinaryPackage() {
@@ -74,3 +83,309 @@ func createExes(exesMap map[string][]string) error {
 	}
 	return nil
 }
+
+func TestGenBinaryPackageCompression(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "debgen-compression")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(outDir)
+	exe := filepath.Join(outDir, "a")
+	if err = ioutil.WriteFile(exe, []byte("echo 1"), 0755); err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	build.TmpDir = filepath.Join(outDir, "tmp")
+	build.DestDir = filepath.Join(outDir, "dist")
+	build.ControlCompressor = targz.CodecZstd
+	build.DataCompressor = targz.CodecXz
+	if err = build.Init(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+	dgen.OrigFiles["/usr/bin/a"] = exe
+	if err = dgen.GenerateAllDefault(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if dgen.DebWriter.ControlArchive != "control.tar.zst" || dgen.DebWriter.DataArchive != "data.tar.xz" {
+		t.Errorf("Unexpected archive names %s, %s", dgen.DebWriter.ControlArchive, dgen.DebWriter.DataArchive)
+	}
+
+	debFile := filepath.Join(build.DestDir, dgen.DebWriter.Filename)
+	rdr, err := os.Open(debFile)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	parsed, err := deb.DebParseMetadata(rdr)
+	rdr.Close()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if parsed.Name != pkg.Name || parsed.Version != pkg.Version {
+		t.Errorf("Unexpected metadata %s %s", parsed.Name, parsed.Version)
+	}
+	// 1 KiB for the file, plus 1 KiB for each directory
+	if parsed.InstalledSize != "4" {
+		t.Errorf("Expected Installed-Size 4, got '%s'", parsed.InstalledSize)
+	}
+	rdr, err = os.Open(debFile)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer rdr.Close()
+	contents, err := deb.DebGetContents(rdr, deb.BinaryDataArchiveNameDefault)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !reflect.DeepEqual(contents, []string{"./", "./usr/", "./usr/bin/", "./usr/bin/a"}) {
+		t.Errorf("Unexpected contents %v", contents)
+	}
+}
+
+func TestGenerateToConcurrently(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	writers, err := deb.NewDebWriters(pkg)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var wg sync.WaitGroup
+	results := make(map[deb.Architecture]*bytes.Buffer)
+	errs := make(map[deb.Architecture]error)
+	var mu sync.Mutex
+	for arch, writer := range writers {
+		wg.Add(1)
+		go func(arch deb.Architecture, writer *deb.DebWriter) {
+			defer wg.Done()
+			buf := new(bytes.Buffer)
+			err := debgen.NewDebGenerator(writer, build).GenerateTo(buf)
+			mu.Lock()
+			results[arch] = buf
+			errs[arch] = err
+			mu.Unlock()
+		}(arch, writer)
+	}
+	wg.Wait()
+	for arch, buf := range results {
+		if errs[arch] != nil {
+			t.Fatalf("%s: %v", arch, errs[arch])
+		}
+		parsed, err := deb.DebParseMetadata(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: %v", arch, err)
+		}
+		if parsed.Architecture != string(arch) {
+			t.Errorf("Expected architecture %s, got %s", arch, parsed.Architecture)
+		}
+	}
+}
+
+func TestGenMd5Sums(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "debgen-md5sums")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(outDir)
+	exe := filepath.Join(outDir, "a")
+	if err = ioutil.WriteFile(exe, []byte("a"), 0755); err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	for _, isMd5sums := range []bool{true, false} {
+		build.IsMd5sums = isMd5sums
+		dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+		dgen.OrigFiles["/usr/bin/a"] = exe
+		dgen.OrigFiles["/etc/a.conf"] = exe
+		dgen.Conffiles = []string{"/etc/a.conf"}
+		buf := new(bytes.Buffer)
+		if err = dgen.GenerateTo(buf); err != nil {
+			t.Fatalf("%v", err)
+		}
+		md5sums := new(bytes.Buffer)
+		err = deb.DebExtractFileL2(bytes.NewReader(buf.Bytes()), deb.BinaryControlArchiveNameDefault, "md5sums", md5sums)
+		if !isMd5sums {
+			if err == nil {
+				t.Errorf("md5sums should not be generated")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		expected := "0cc175b9c0f1b6a831c399e269772661  usr/bin/a\n"
+		if md5sums.String() != expected {
+			t.Errorf("Expected md5sums '%s', got '%s'", expected, md5sums.String())
+		}
+	}
+}
+
+func TestGenConffiles(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "debgen-conffiles")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(outDir)
+	conf := filepath.Join(outDir, "a.conf")
+	if err = ioutil.WriteFile(conf, []byte("a=1\n"), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+	dgen.OrigFiles["etc/testpkg/a.conf"] = conf
+	dgen.OrigFiles["/opt/testpkg/a.conf"] = conf
+	dgen.OrigFiles["/usr/share/testpkg/a.conf"] = conf
+	dgen.Conffiles = []string{"/opt/testpkg/a.conf"}
+	buf := new(bytes.Buffer)
+	if err = dgen.GenerateTo(buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	conffiles, err := deb.DebGetConffiles(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := []string{"/etc/testpkg/a.conf", "/opt/testpkg/a.conf"}
+	if !reflect.DeepEqual(conffiles, expected) {
+		t.Errorf("Expected conffiles %v, got %v", expected, conffiles)
+	}
+
+	build.IsAutoConffiles = false
+	if !reflect.DeepEqual(dgen.AllConffiles(), []string{"/opt/testpkg/a.conf"}) {
+		t.Errorf("Unexpected conffiles without /etc: %v", dgen.AllConffiles())
+	}
+	dgen.Conffiles = []string{"/opt/testpkg/missing.conf"}
+	if err = dgen.GenerateTo(new(bytes.Buffer)); err == nil {
+		t.Errorf("Expected an error for a missing conffile")
+	}
+}
+
+func TestGenerateReproducible(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "debgen-reproducible")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(outDir)
+	exe := filepath.Join(outDir, "a")
+	if err = ioutil.WriteFile(exe, []byte("a"), 0755); err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	build.IsReproducible = true
+	build.SourceDateEpoch = time.Unix(1500000000, 0).UTC()
+	outputs := [][]byte{}
+	for i, mode := range []os.FileMode{0755, 0775} {
+		// vary the file's mtime and umask-dependent permissions between builds. The output should not change.
+		mtime := time.Now().Add(time.Duration(i) * time.Hour)
+		if err = os.Chtimes(exe, mtime, mtime); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err = os.Chmod(exe, mode); err != nil {
+			t.Fatalf("%v", err)
+		}
+		dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+		dgen.OrigFiles["/usr/bin/a"] = exe
+		buf := new(bytes.Buffer)
+		if err = dgen.GenerateTo(buf); err != nil {
+			t.Fatalf("%v", err)
+		}
+		outputs = append(outputs, buf.Bytes())
+	}
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Errorf("Reproducible builds differ")
+	}
+	arr, err := ar.NewReader(bytes.NewReader(outputs[0]))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	hdr, err := arr.Next()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !hdr.ModTime.Equal(build.SourceDateEpoch) {
+		t.Errorf("Expected ar member mtime %v, got %v", build.SourceDateEpoch, hdr.ModTime)
+	}
+}
+
+func TestGenControlMembers(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+	dgen.ControlFiles["triggers"] = []byte("activate-noawait ldconfig\n")
+	dgen.ControlFiles["config"] = []byte("#!/bin/sh\n")
+	buf := new(bytes.Buffer)
+	if err := dgen.GenerateTo(buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	modes := map[string]int64{}
+	rdr, err := deb.NewDebReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for {
+		name, tr, err := rdr.NextTar()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if name != deb.BinaryControlArchiveNameDefault {
+			continue
+		}
+		for {
+			hdr, err := tr.Next()
+			if err != nil {
+				break
+			}
+			modes[hdr.Name] = hdr.Mode
+		}
+		break
+	}
+	if modes["config"] != 0755 || modes["triggers"] != 0644 {
+		t.Errorf("Expected config with mode 755 and triggers with mode 644, got %v", modes)
+	}
+
+	dgen.ControlFiles["triggers"] = []byte("activate\n")
+	if err = dgen.GenerateTo(new(bytes.Buffer)); err == nil {
+		t.Errorf("Invalid triggers should fail")
+	}
+	delete(dgen.ControlFiles, "triggers")
+	dgen.ControlFiles["foo"] = []byte("foo")
+	if err = dgen.GenerateTo(new(bytes.Buffer)); err == nil {
+		t.Errorf("Unsupported control member should fail")
+	}
+}
+
+func TestGenDebconf(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+	dgen.DebconfTemplates = []*deb.DebconfTemplate{
+		deb.NewDebconfTemplate("testpkg/enable", "boolean", "Enable testpkg?"),
+		deb.NewDebconfTemplate("testpkg/title", "title", "Testpkg"),
+	}
+	buf := new(bytes.Buffer)
+	if err := dgen.GenerateTo(buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	templates, err := deb.DebGetDebconfTemplates(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(templates) != 2 || templates[0].Template != "testpkg/enable" {
+		t.Errorf("Unexpected templates %v", templates)
+	}
+	config := new(bytes.Buffer)
+	err = deb.DebExtractFileL2(bytes.NewReader(buf.Bytes()), deb.BinaryControlArchiveNameDefault, "config", config)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !strings.Contains(config.String(), "db_input medium testpkg/enable || true\ndb_go") {
+		t.Errorf("Unexpected config script:\n%s", config.String())
+	}
+
+	dgen.DebconfTemplates = append(dgen.DebconfTemplates, deb.NewDebconfTemplate("testpkg/enable", "boolean", "Again?"))
+	if err = dgen.GenerateTo(new(bytes.Buffer)); err == nil {
+		t.Errorf("Duplicate templates should fail")
+	}
+}
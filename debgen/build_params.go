@@ -17,11 +17,21 @@
 package debgen
 
 import (
+	"archive/tar"
 	"errors"
 	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/targz"
+	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
+// SourceDateEpochEnv is the environment variable defining the timestamp for reproducible builds.
+//
+// See https://reproducible-builds.org/specs/source-date-epoch/
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
 // BuildParams provides information about a particular build
 type BuildParams struct {
 	// Properties below are mainly for build-related properties rather than metadata
@@ -35,10 +45,25 @@ type BuildParams struct {
 	TemplateDir  string // Optional. Only required if you're using templates
 	ResourcesDir string // Optional. Only if debgo packages your resources automatically.
 
+	Arches []deb.Architecture // Optional. Concrete architectures which 'any' (or another wildcard) resolves to. By default, those with binaries available.
+
+	ControlCompressor       string // Compression for the control archive: gzip (default), xz, zstd or none
+	ControlCompressionLevel int    // Compression level for the control archive. See targz.DefaultCompression
+	DataCompressor          string // Compression for the data archive: gzip (default), xz, zstd or none
+	DataCompressionLevel    int    // Compression level for the data archive. See targz.DefaultCompression
+
+	IsMd5sums       bool // Whether to generate an md5sums control file. Default true
+	IsAutoConffiles bool // Whether to mark all files under /etc as conffiles. Default true
+	IsSysusersConf  bool // Whether to add a sysusers.d configuration for the package's system accounts. Default false
+	IsTmpfilesConf  bool // Whether to add a tmpfiles.d configuration for the home directories of the package's system users. Default false
+
+	IsReproducible  bool      // Whether to generate byte-for-byte reproducible output. Default true when SOURCE_DATE_EPOCH is set
+	SourceDateEpoch time.Time // Timestamp for reproducible output. Later modification times are clamped to this. Defaults to SOURCE_DATE_EPOCH
+
 	//TemplateStringsSource map[string]string //Populate this to fulfil templates for the different control files.
 }
 
-//Factory for BuildParams. Populates defaults.
+// Factory for BuildParams. Populates defaults.
 func NewBuildParams() *BuildParams {
 	bp := &BuildParams{IsVerbose: false}
 	bp.TmpDir = deb.TempDirDefault
@@ -47,11 +72,45 @@ func NewBuildParams() *BuildParams {
 	bp.WorkingDir = deb.WorkingDirDefault
 	bp.TemplateDir = deb.TemplateDirDefault
 	bp.ResourcesDir = deb.ResourcesDirDefault
+	bp.ControlCompressor = targz.CodecGzip
+	bp.ControlCompressionLevel = targz.DefaultCompression
+	bp.DataCompressor = targz.CodecGzip
+	bp.DataCompressionLevel = targz.DefaultCompression
+	bp.IsMd5sums = true
+	bp.IsAutoConffiles = true
+	if epoch := os.Getenv(SourceDateEpochEnv); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			log.Printf("Ignoring invalid %s '%s'", SourceDateEpochEnv, epoch)
+		} else {
+			bp.IsReproducible = true
+			bp.SourceDateEpoch = time.Unix(seconds, 0).UTC()
+		}
+	}
 	return bp
 }
 
+// Timestamp returns the time to use for generated files.
+// For reproducible builds this is SourceDateEpoch, or the Unix epoch if that's unset. Otherwise the current time.
+func (bp *BuildParams) Timestamp() time.Time {
+	if bp.IsReproducible {
+		if bp.SourceDateEpoch.IsZero() {
+			return time.Unix(0, 0).UTC()
+		}
+		return bp.SourceDateEpoch
+	}
+	return time.Now()
+}
+
+// TarWriter returns tw, wrapped to normalise entries for reproducible builds if necessary (see NewReproducibleTarWriter).
+func (bp *BuildParams) TarWriter(tw *tar.Writer) TarWriter {
+	if bp.IsReproducible {
+		return NewReproducibleTarWriter(tw, bp.Timestamp())
+	}
+	return tw
+}
 
-//Initialise build directories (make Temp and Dest directories)
+// Initialise build directories (make Temp and Dest directories)
 func (bp *BuildParams) Init() error {
 	//make tmpDir
 	if bp.TmpDir == "" {
@@ -0,0 +1,53 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package debgen
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"io"
+	"regexp"
+)
+
+var changelogHeaderRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9+.-]+ \(([^)]+)\)`)
+
+// LatestChangelogVersion finds the version of the most recently written entry in a changelog.
+// debgen-changelog appends entries, so this is the version in the last entry header.
+// (Versions can't be compared instead, because backport, PPA and pre-release versions sort before the versions they're based on.)
+func LatestChangelogVersion(rdr io.Reader) (string, error) {
+	latest := ""
+	scanner := bufio.NewScanner(rdr)
+	for scanner.Scan() {
+		matches := changelogHeaderRegexp.FindStringSubmatch(scanner.Text())
+		if matches == nil {
+			continue
+		}
+		err := deb.ValidateVersion(matches[1])
+		if err != nil {
+			return "", err
+		}
+		latest = matches[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if latest == "" {
+		return "", fmt.Errorf("No versions found in changelog")
+	}
+	return latest, nil
+}
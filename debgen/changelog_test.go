@@ -0,0 +1,60 @@
+package debgen_test
+
+import (
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/debgen"
+	"strings"
+	"testing"
+)
+
+func TestLatestChangelogVersion(t *testing.T) {
+	changelog := `testpkg (0.9-1) unstable; urgency=low
+
+  * Initial import
+
+ -- me <a@me.org>  Mon, 02 Jan 2006 15:04:05 -0700
+
+testpkg (0.10-1) unstable; urgency=low
+
+  * Bump
+
+ -- me <a@me.org>  Tue, 03 Jan 2006 15:04:05 -0700
+`
+	version, err := debgen.LatestChangelogVersion(strings.NewReader(changelog))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if version != "0.10-1" {
+		t.Errorf("Expected 0.10-1, got %s", version)
+	}
+	_, err = debgen.LatestChangelogVersion(strings.NewReader(""))
+	if err == nil {
+		t.Errorf("Expected an error for an empty changelog")
+	}
+}
+
+func TestRepeatedChangelogBumps(t *testing.T) {
+	tests := map[string][]string{
+		"ppa":         {"1.2-1~ppa1", "1.2-1~ppa2"},
+		"backport:12": {"1.2-1~bpo12+1", "1.2-1~bpo12+2"},
+	}
+	for bump, expected := range tests {
+		changelog := "testpkg (1.2-1) unstable; urgency=low\n\n  * Initial import\n\n -- me <a@me.org>  Mon, 02 Jan 2006 15:04:05 -0700\n"
+		for _, expectedVersion := range expected {
+			latest, err := debgen.LatestChangelogVersion(strings.NewReader(changelog))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			version, err := deb.BumpVersion(latest, bump)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if version != expectedVersion {
+				t.Errorf("%s: expected %s, got %s", bump, expectedVersion, version)
+			}
+			// entries are appended
+			changelog += fmt.Sprintf("\ntestpkg (%s) unstable; urgency=low\n\n  * Bump\n\n -- me <a@me.org>  Tue, 03 Jan 2006 15:04:05 -0700\n", version)
+		}
+	}
+}
@@ -6,7 +6,7 @@ import (
 
 const (
 	GlobGoSources               = "*.go"
-	TemplateDebianSourceFormat  = deb.FormatDefault                                      // Debian source formaat
+	TemplateDebianSourceFormat  = deb.FormatDefault // Debian source formaat
 	TemplateDebianSourceOptions = `tar-ignore = .hg
 tar-ignore = .git
 tar-ignore = .bzr` //specifies files to ignore while building.
@@ -69,16 +69,46 @@ binary-arch: clean
 
 binary: binary-arch`
 
-	// The debian control file (binary debs) defines package metadata
+	// The debian control file (binary debs) defines package metadata.
+	// This is not used by default (see deb.DebWriter.WriteControl), but it can be used as a starting point for a 'control.tpl' template.
 	TemplateBinarydebControl = `Package: {{.Package.Name}}
 Priority: {{.Package.Priority}}
 {{if .Package.Maintainer}}Maintainer: {{.Package.Maintainer}}
 {{end}}Section: {{.Package.Section}}
 Version: {{.Package.Version}}
 Architecture: {{.Deb.Architecture}}
-{{if .Package.Depends}}Depends: {{.Package.Depends}}
-{{end}}{{range $key, $value := .Package.AdditionalControlData}}{{$key}}: {{$value}}
+{{if .InstalledSize}}Installed-Size: {{.InstalledSize}}
+{{end}}{{if .Package.Depends}}Depends: {{.Package.Depends}}
+{{end}}{{range .Package.AdditionalControlData.Fields}}{{.Name}}: {{.Value}}
 {{end}}Description: {{.Package.Description}}
+`
+
+	// The debconf config script (binary debs) asks each of the debconf templates' questions.
+	// This is used by default when DebGenerator.DebconfTemplates are defined.
+	TemplateDebconfConfig = `#!/bin/sh
+set -e
+
+. /usr/share/debconf/confmodule
+
+{{range .DebconfTemplates}}{{if and (ne .Type "title") (ne .Type "error")}}db_input medium {{.Template}} || true
+{{end}}{{end}}db_go || true
+`
+
+	// A systemd service unit, generated from a SystemdService
+	TemplateSystemdService = `[Unit]
+Description={{.Service.Description}}
+{{if .Service.After}}After={{.Service.After}}
+{{end}}
+[Service]
+{{if .Service.Type}}Type={{.Service.Type}}
+{{end}}ExecStart={{.Service.ExecStart}}
+{{if .Service.User}}User={{.Service.User}}
+{{end}}{{if .Service.Group}}Group={{.Service.Group}}
+{{end}}{{range .Service.Environment}}Environment={{.}}
+{{end}}{{if .Service.Restart}}Restart={{.Service.Restart}}
+{{end}}
+[Install]
+WantedBy={{.Service.WantedBy}}
 `
 
 	// The debian control file (source debs) defines build metadata AND package metadata
@@ -114,13 +144,13 @@ Files:{{range .Checksums.ChecksumsMd5}}
  {{.Checksum}} {{.Size}} {{.File}}{{end}}
 {{.Package.Other}}`
 
-	TemplateChangelogHeader       = `{{.Package.Name}} ({{.Package.Version}}) {{.Package.Status}}; urgency=low`
-	TemplateChangelogInitialEntry = `  * Initial import`
-	TemplateChangelogFooter       = ` -- {{.Package.Maintainer}}  {{.EntryDate}}`
-	TemplateChangelogInitial      = TemplateChangelogHeader + "\n\n" + TemplateChangelogInitialEntry + "\n\n" + TemplateChangelogFooter
-	TemplateChangelogAdditionalEntry  = "\n\n" + TemplateChangelogHeader + "\n\n{{.ChangelogEntry}}\n\n" + TemplateChangelogFooter
-	TemplateDebianCopyright       = `Copyright 2014 {{.Package.Name}}`
-	TemplateDebianReadme          = `{{.Package.Name}}
+	TemplateChangelogHeader          = `{{.Package.Name}} ({{.Package.Version}}) {{.Package.Status}}; urgency=low`
+	TemplateChangelogInitialEntry    = `  * Initial import`
+	TemplateChangelogFooter          = ` -- {{.Package.Maintainer}}  {{.EntryDate}}`
+	TemplateChangelogInitial         = TemplateChangelogHeader + "\n\n" + TemplateChangelogInitialEntry + "\n\n" + TemplateChangelogFooter
+	TemplateChangelogAdditionalEntry = "\n\n" + TemplateChangelogHeader + "\n\n{{.ChangelogEntry}}\n\n" + TemplateChangelogFooter
+	TemplateDebianCopyright          = `Copyright 2014 {{.Package.Name}}`
+	TemplateDebianReadme             = `{{.Package.Name}}
 ==========
 
 `
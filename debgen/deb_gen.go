@@ -17,47 +17,62 @@
 package debgen
 
 import (
+	"archive/tar"
+	"bytes"
+	"fmt"
 	"github.com/laher/debgo-v0.2/deb"
 	"github.com/laher/debgo-v0.2/targz"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
-//DebGenerator generates source packages using templates and some overrideable behaviours
+// DebGenerator generates source packages using templates and some overrideable behaviours
 type DebGenerator struct {
-	DebWriter *deb.DebWriter
-	BuildParams *BuildParams
+	DebWriter              *deb.DebWriter
+	BuildParams            *BuildParams
 	DefaultTemplateStrings map[string]string
-	OrigFiles map[string]string
+	OrigFiles              map[string]string
+	Conffiles              []string               // Paths of conffiles, e.g. /opt/foo/foo.conf. Files under /etc are added automatically (see BuildParams.IsAutoConffiles)
+	Md5Sums                map[string]string      // MD5 digests of the data archive's files. Populated by WriteDataArchive
+	DataEntries            []*TarEntry            // The data archive's entries. Populated by WriteDataArchive
+	ControlFiles           map[string][]byte      // Contents of optional control archive members, e.g. 'triggers'. See deb.ControlMembers
+	Snippets               *ScriptSnippets        // Snippets for the maintainer scripts, contributed by debgen or its users
+	Alternatives           []*Alternative         // Alternatives installed with update-alternatives. Paths must be in the data archive
+	Diversions             []*Diversion           // Files diverted with dpkg-divert
+	SystemdServices        []*SystemdService      // systemd units, installed into the data archive and managed by the maintainer scripts (see AddSystemdService)
+	Maintscripts           []*Maintscript         // dpkg-maintscript-helper directives, for conffile and path migrations. The required dpkg is added to the control file's Pre-Depends
+	DebconfTemplates       []*deb.DebconfTemplate // Debconf templates, written as the 'templates' control member, with a default 'config' script (see TemplateDebconfConfig)
 }
 
-//NewDebGenerator is a factory for SourcePackageGenerator.
+// NewDebGenerator is a factory for SourcePackageGenerator.
 func NewDebGenerator(debWriter *deb.DebWriter, buildParams *BuildParams) *DebGenerator {
-	dgen := &DebGenerator{DebWriter:debWriter, BuildParams:buildParams,
-		DefaultTemplateStrings:map[string]string{}, OrigFiles:map[string]string{}}
+	dgen := &DebGenerator{DebWriter: debWriter, BuildParams: buildParams,
+		DefaultTemplateStrings: map[string]string{}, OrigFiles: map[string]string{}, ControlFiles: map[string][]byte{}, Snippets: NewScriptSnippets()}
 	return dgen
 }
 
 // GenerateAllDefault applies the default build process.
-// First it writes each file, then adds them to the .deb as a separate io operation.
+// The .deb is streamed into BuildParams.DestDir (see GenerateTo).
 func (dgen *DebGenerator) GenerateAllDefault() error {
 	if dgen.BuildParams.IsVerbose {
-		log.Printf("trying to write control file for %s", dgen.DebWriter.Architecture)
+		log.Printf("trying to write .deb file for %s", dgen.DebWriter.Architecture)
 	}
-	err := dgen.GenControlArchive()
+	wtr, err := os.Create(filepath.Join(dgen.BuildParams.DestDir, dgen.DebWriter.Filename))
 	if err != nil {
 		return err
 	}
-	err = dgen.GenDataArchive()
+	defer wtr.Close()
+	err = dgen.GenerateTo(wtr)
 	if err != nil {
 		return err
 	}
-	if dgen.BuildParams.IsVerbose {
-		log.Printf("trying to write .deb file for %s", dgen.DebWriter.Architecture)
-	}
-	//TODO switch this around
-	err = dgen.DebWriter.Build(dgen.BuildParams.TmpDir, dgen.BuildParams.DestDir)
+	err = wtr.Close()
 	if err != nil {
 		return err
 	}
@@ -67,18 +82,52 @@ func (dgen *DebGenerator) GenerateAllDefault() error {
 	return err
 }
 
-//GenControlArchive generates control archive, using a system of templates or files.
-//
-//First it attempts to find the file inside BuildParams.Resources.
-//If that doesn't exist, it attempts to find a template in templateDir
-//Finally, it attempts to use a string-based template.
+// GenerateTo generates the data and control archives, and writes the .deb to w.
+// Only the data archive is spooled (to BuildParams.TmpDir, once it's too large to hold in memory), so generators can run concurrently.
+func (dgen *DebGenerator) GenerateTo(w io.Writer) error {
+	if dgen.BuildParams.IsReproducible {
+		dgen.DebWriter.ModTime = dgen.BuildParams.Timestamp()
+	}
+	return dgen.DebWriter.WriteDeb(w, dgen.BuildParams.TmpDir, dgen.WriteControlArchive, dgen.WriteDataArchive)
+}
+
+// GenControlArchive generates the control archive into BuildParams.TmpDir (see WriteControlArchive).
+// md5sums are only included if GenDataArchive has been called first.
+// The filename is the same for every architecture, so this is not suitable for concurrent builds.
 func (dgen *DebGenerator) GenControlArchive() error {
-	archiveFilename := filepath.Join(dgen.BuildParams.TmpDir, dgen.DebWriter.ControlArchive)
-	controlTgzw, err := targz.NewWriterFromFile(archiveFilename)
+	codec, err := targz.CodecByName(dgen.BuildParams.ControlCompressor)
+	if err != nil {
+		return err
+	}
+	archiveFilename := filepath.Join(dgen.BuildParams.TmpDir, archiveName(deb.BinaryControlArchiveNameDefault, codec))
+	wtr, err := os.Create(archiveFilename)
+	if err != nil {
+		return err
+	}
+	defer wtr.Close()
+	err = dgen.WriteControlArchive(wtr)
+	if err != nil {
+		return err
+	}
+	return wtr.Close()
+}
+
+// WriteControlArchive generates the control archive, using a system of templates or files.
+//
+// First it attempts to find the file inside BuildParams.Resources.
+// If that doesn't exist, it attempts to find a template in templateDir
+// Finally, it attempts to use a string-based template.
+func (dgen *DebGenerator) WriteControlArchive(w io.Writer) error {
+	codec, err := targz.CodecByName(dgen.BuildParams.ControlCompressor)
+	if err != nil {
+		return err
+	}
+	dgen.DebWriter.ControlArchive = archiveName(deb.BinaryControlArchiveNameDefault, codec)
+	controlTgzw, err := targz.NewWriterWithCodec(w, codec, dgen.BuildParams.ControlCompressionLevel)
 	if err != nil {
 		return err
 	}
-	templateVars := &TemplateData{Package: dgen.DebWriter.Package, Deb: dgen.DebWriter}
+	templateVars := &TemplateData{Package: dgen.DebWriter.Package, Deb: dgen.DebWriter, InstalledSize: dgen.DebWriter.InstalledSizeField(), DebconfTemplates: dgen.DebconfTemplates}
 	//templateVars.Deb = dgen.DebWriter
 
 	err = dgen.GenControlFile(controlTgzw, templateVars)
@@ -88,31 +137,20 @@ func (dgen *DebGenerator) GenControlArchive() error {
 	if dgen.BuildParams.IsVerbose {
 		log.Printf("Wrote control file to control archive")
 	}
-	// This is where you include Postrm/Postinst etc
-	for _, scriptName := range deb.MaintainerScripts {
-		resourcePath := filepath.Join(dgen.BuildParams.ResourcesDir, DebianDir, scriptName)
-		_, err = os.Stat(resourcePath)
-		if err == nil {
-			err = TarAddFile(controlTgzw.Writer, resourcePath, scriptName)
-			if err != nil {
-				return err
-			}
-		} else {
-			templatePath := filepath.Join(dgen.BuildParams.TemplateDir, DebianDir, scriptName+TplExtension)
-			_, err = os.Stat(templatePath)
-			//TODO handle non-EOF errors
-			if err == nil {
-				scriptData, err := TemplateFile(templatePath, templateVars)
-				if err != nil {
-					return err
-				}
-				err = TarAddBytes(controlTgzw.Writer, scriptData, scriptName, 0755)
-				if err != nil {
-					return err
-				}
-			}
+	if dgen.BuildParams.IsMd5sums && dgen.Md5Sums != nil {
+		err = dgen.GenMd5SumsFile(controlTgzw)
+		if err != nil {
+			return err
 		}
 	}
+	err = dgen.GenConffilesFile(controlTgzw)
+	if err != nil {
+		return err
+	}
+	err = dgen.GenControlMembers(controlTgzw, templateVars)
+	if err != nil {
+		return err
+	}
 
 	err = controlTgzw.Close()
 	if err != nil {
@@ -124,27 +162,64 @@ func (dgen *DebGenerator) GenControlArchive() error {
 	return err
 }
 
-// GenDataArchive generates the 'code' archive from files on the file system.
+// GenDataArchive generates the data archive into BuildParams.TmpDir (see WriteDataArchive).
+// The filename is the same for every architecture, so this is not suitable for concurrent builds.
 func (dgen *DebGenerator) GenDataArchive() error {
-	archiveFilename := filepath.Join(dgen.BuildParams.TmpDir, dgen.DebWriter.DataArchive)
-	dataTgzw, err := targz.NewWriterFromFile(archiveFilename)
+	codec, err := targz.CodecByName(dgen.BuildParams.DataCompressor)
 	if err != nil {
 		return err
 	}
-	err = TarAddFiles(dataTgzw.Writer, dgen.OrigFiles)
+	archiveFilename := filepath.Join(dgen.BuildParams.TmpDir, archiveName(deb.BinaryDataArchiveNameDefault, codec))
+	wtr, err := os.Create(archiveFilename)
 	if err != nil {
 		return err
 	}
-	if dgen.BuildParams.IsVerbose {
-		log.Printf("Added executables")
+	defer wtr.Close()
+	err = dgen.WriteDataArchive(wtr)
+	if err != nil {
+		return err
 	}
-/*
-	// TODO add README.debian automatically
-	err = TarAddFiles(dataTgzw.Writer, dgen.DebWriter.Package.MappedFiles)
+	return wtr.Close()
+}
+
+// WriteDataArchive generates the 'code' archive from files on the file system.
+func (dgen *DebGenerator) WriteDataArchive(w io.Writer) error {
+	codec, err := targz.CodecByName(dgen.BuildParams.DataCompressor)
 	if err != nil {
 		return err
 	}
-*/
+	dgen.DebWriter.DataArchive = archiveName(deb.BinaryDataArchiveNameDefault, codec)
+	dataTgzw, err := targz.NewWriterWithCodec(w, codec, dgen.BuildParams.DataCompressionLevel)
+	if err != nil {
+		return err
+	}
+	generated, err := dgen.generatedDataFiles()
+	if err != nil {
+		return err
+	}
+	dgen.DataEntries, err = TarEntriesWithContents(dgen.OrigFiles, generated)
+	if err != nil {
+		return err
+	}
+	dgen.Md5Sums = map[string]string{}
+	err = WriteTarEntries(dgen.BuildParams.TarWriter(dataTgzw.Writer), dgen.DataEntries, dgen.Md5Sums)
+	if err != nil {
+		return err
+	}
+	dgen.DebWriter.InstalledSize = 0
+	for _, entry := range dgen.DataEntries {
+		dgen.DebWriter.InstalledSize += deb.InstalledSizeKiB(entry.Header())
+	}
+	if dgen.BuildParams.IsVerbose {
+		log.Printf("Added executables")
+	}
+	/*
+		// TODO add README.debian automatically
+		err = TarAddFiles(dataTgzw.Writer, dgen.DebWriter.Package.MappedFiles)
+		if err != nil {
+			return err
+		}
+	*/
 	if dgen.BuildParams.IsVerbose {
 		log.Printf("Added resources")
 	}
@@ -158,22 +233,340 @@ func (dgen *DebGenerator) GenDataArchive() error {
 	return err
 }
 
-//Generates the control file based on a template
+// generatedDataFiles returns the contents of files generated for the data archive, by destination path
+func (dgen *DebGenerator) generatedDataFiles() (map[string][]byte, error) {
+	files, err := dgen.systemdUnitFiles()
+	if err != nil {
+		return nil, err
+	}
+	pkg := dgen.DebWriter.Package
+	if dgen.BuildParams.IsSysusersConf {
+		if conf := SysusersConf(pkg); conf != nil {
+			files[path.Join(SysusersDirDefault, pkg.Name+".conf")] = conf
+		}
+	}
+	if dgen.BuildParams.IsTmpfilesConf {
+		if conf := TmpfilesConf(pkg); conf != nil {
+			files[path.Join(TmpfilesDirDefault, pkg.Name+".conf")] = conf
+		}
+	}
+	return files, nil
+}
+
+// archiveName names an archive according to its compression, e.g. data.tar.xz
+func archiveName(defaultName string, codec targz.Codec) string {
+	return targz.TrimExtension(defaultName) + ".tar" + codec.Extension()
+}
+
+// GenMd5SumsFile writes the md5sums control file, excluding conffiles (as dpkg-deb does).
+func (dgen *DebGenerator) GenMd5SumsFile(tgzw *targz.Writer) error {
+	md5sums := map[string]string{}
+	for path, sum := range dgen.Md5Sums {
+		md5sums[path] = sum
+	}
+	for _, conffile := range dgen.AllConffiles() {
+		delete(md5sums, deb.Md5SumsPath(conffile))
+	}
+	var buf bytes.Buffer
+	err := deb.WriteMd5Sums(&buf, md5sums)
+	if err != nil {
+		return err
+	}
+	return TarAddBytes(dgen.BuildParams.TarWriter(tgzw.Writer), buf.Bytes(), "md5sums", 0644)
+}
+
+// AllConffiles lists the explicit Conffiles, plus files under /etc (unless BuildParams.IsAutoConffiles is false).
+// Paths are absolute, sorted and unique.
+func (dgen *DebGenerator) AllConffiles() []string {
+	unique := map[string]bool{}
+	for _, conffile := range dgen.Conffiles {
+		unique[deb.ConffilePath(conffile)] = true
+	}
+	if dgen.BuildParams.IsAutoConffiles {
+		for _, name := range dgen.dataFiles() {
+			path := deb.ConffilePath(name)
+			if strings.HasPrefix(path, deb.ConffilesDirDefault+"/") {
+				unique[path] = true
+			}
+		}
+	}
+	conffiles := []string{}
+	for conffile := range unique {
+		conffiles = append(conffiles, conffile)
+	}
+	sort.Strings(conffiles)
+	return conffiles
+}
+
+// dataFiles lists the data archive's files (not directories or links).
+// Before the data archive is generated, this is just the mapped files.
+func (dgen *DebGenerator) dataFiles() []string {
+	files := []string{}
+	if dgen.DataEntries == nil {
+		for name := range dgen.OrigFiles {
+			files = append(files, name)
+		}
+		return files
+	}
+	for _, entry := range dgen.DataEntries {
+		if entry.Type == tar.TypeReg || entry.Type == tar.TypeLink {
+			files = append(files, entry.Name)
+		}
+	}
+	return files
+}
+
+// GenConffilesFile writes the conffiles control file, if there are any conffiles.
+// Returns an error if any conffile is missing from the data archive.
+func (dgen *DebGenerator) GenConffilesFile(tgzw *targz.Writer) error {
+	conffiles := dgen.AllConffiles()
+	if len(conffiles) == 0 {
+		return nil
+	}
+	err := deb.ValidateConffiles(conffiles, dgen.dataFiles())
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	err = deb.WriteConffiles(&buf, conffiles)
+	if err != nil {
+		return err
+	}
+	return TarAddBytes(dgen.BuildParams.TarWriter(tgzw.Writer), buf.Bytes(), "conffiles", 0644)
+}
+
+// GenControlMembers writes the optional control archive members (maintainer scripts, triggers, shlibs, etc. See deb.ControlMembers).
+//
+// Each member is taken from the first of: a file in BuildParams.ResourcesDir/debian, a template in BuildParams.TemplateDir/debian,
+// ControlFiles, or a template string in DefaultTemplateStrings. Members not found are skipped.
+// Snippets are added to the maintainer scripts (see ScriptSnippets.Apply), along with snippets for the package's system accounts, Maintscripts, Diversions and Alternatives.
+// If DebconfTemplates are defined, they're used for 'templates', and TemplateDebconfConfig for 'config', unless those are supplied elsewhere.
+// Contents are validated before writing (see deb.ValidateControlMember). Executables (maintainer scripts and config) are written with mode 0755, the rest 0644.
+func (dgen *DebGenerator) GenControlMembers(tgzw *targz.Writer, templateVars *TemplateData) error {
+	for name := range dgen.ControlFiles {
+		if !deb.IsControlMember(name) {
+			return fmt.Errorf("Unsupported control member '%s'", name)
+		}
+	}
+	err := deb.ValidateSystemAccounts(dgen.DebWriter.Package)
+	if err != nil {
+		return err
+	}
+	err = ValidateAlternatives(dgen.Alternatives, dgen.dataFiles())
+	if err != nil {
+		return err
+	}
+	err = ValidateDiversions(dgen.Diversions, dgen.dataFiles())
+	if err != nil {
+		return err
+	}
+	err = ValidateMaintscripts(dgen.Maintscripts, dgen.DebWriter.Package.Version, dgen.dataFiles(), dgen.AllConffiles())
+	if err != nil {
+		return err
+	}
+	// DataEntries are only known once the data archive is generated
+	if dgen.DataEntries != nil {
+		err = ValidateMaintscriptEntries(dgen.Maintscripts, dgen.DataEntries)
+		if err != nil {
+			return err
+		}
+	}
+	snippets, err := dgen.allSnippets()
+	if err != nil {
+		return err
+	}
+	for _, name := range deb.ControlMembers {
+		data, err := dgen.controlMemberData(name, templateVars)
+		if err != nil {
+			return err
+		}
+		if _, isScript := deb.MaintainerScriptActions[name]; isScript {
+			if data != nil && !bytes.Contains(data, []byte(DebhelperToken)) && len(snippets.ForScript(name)) > 0 {
+				log.Printf("Warning: %s has no %s token, so its snippets are omitted", name, DebhelperToken)
+			}
+			data = snippets.Apply(name, data)
+		}
+		if data == nil {
+			continue
+		}
+		err = deb.ValidateControlMember(name, data)
+		if err != nil {
+			return err
+		}
+		err = TarAddBytes(dgen.BuildParams.TarWriter(tgzw.Writer), data, name, deb.ControlMemberMode(name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allSnippets returns the Snippets, plus those generated from the package's metadata and the generator's declarations
+func (dgen *DebGenerator) allSnippets() (*ScriptSnippets, error) {
+	ss := NewScriptSnippets()
+	if dgen.Snippets != nil {
+		ss.snippets = append(ss.snippets, dgen.Snippets.snippets...)
+	}
+	generated := SystemAccountsSnippets(dgen.DebWriter.Package)
+	generated = append(generated, MaintscriptSnippets(dgen.Maintscripts)...)
+	generated = append(generated, DiversionsSnippets(dgen.DebWriter.Package.Name, dgen.Diversions)...)
+	generated = append(generated, AlternativesSnippets(dgen.Alternatives)...)
+	for _, svc := range dgen.SystemdServices {
+		generated = append(generated, SystemdSnippets(svc)...)
+	}
+	for _, snippet := range generated {
+		err := ss.Add(snippet)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ss, nil
+}
+
+// controlMemberData finds a control member's contents. Returns nil if it's not supplied.
+func (dgen *DebGenerator) controlMemberData(name string, templateVars *TemplateData) ([]byte, error) {
+	resourcePath := filepath.Join(dgen.BuildParams.ResourcesDir, DebianDir, name)
+	_, err := os.Stat(resourcePath)
+	if err == nil {
+		return ioutil.ReadFile(resourcePath)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	templatePath := filepath.Join(dgen.BuildParams.TemplateDir, DebianDir, name+TplExtension)
+	_, err = os.Stat(templatePath)
+	if err == nil {
+		return TemplateFile(templatePath, templateVars)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if data, ok := dgen.ControlFiles[name]; ok {
+		return data, nil
+	}
+	if templateString, ok := dgen.DefaultTemplateStrings[name]; ok {
+		return TemplateString(templateString, templateVars)
+	}
+	if len(dgen.DebconfTemplates) > 0 {
+		switch name {
+		case "templates":
+			var buf bytes.Buffer
+			err = deb.WriteDebconfTemplates(&buf, dgen.DebconfTemplates)
+			return buf.Bytes(), err
+		case "config":
+			return TemplateString(TemplateDebconfConfig, templateVars)
+		}
+	}
+	return nil, nil
+}
+
+// Generates the control file.
+//
+// First it attempts to find the file inside BuildParams.Resources.
+// If that doesn't exist, it attempts to find a template in templateDir, or a 'control' entry in DefaultTemplateStrings.
+// Otherwise, the control file is generated directly from the package metadata.
 func (dgen *DebGenerator) GenControlFile(tgzw *targz.Writer, templateVars *TemplateData) error {
+	var controlData []byte
+	isGenerated := false
+	isResource := false
 	resourcePath := filepath.Join(dgen.BuildParams.ResourcesDir, "debian", "control")
+	templatePath := filepath.Join(dgen.BuildParams.TemplateDir, "control.tpl")
 	_, err := os.Stat(resourcePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
 	if err == nil {
-		err = TarAddFile(tgzw.Writer, resourcePath, "control")
+		isResource = true
+		controlData, err = ioutil.ReadFile(resourcePath)
+	} else if _, err = os.Stat(templatePath); err == nil {
+		controlData, err = TemplateFile(templatePath, templateVars)
+	} else if !os.IsNotExist(err) {
 		return err
+	} else if templateString, ok := dgen.DefaultTemplateStrings["control"]; ok {
+		controlData, err = TemplateString(templateString, templateVars)
+	} else {
+		isGenerated = true
+		para := dgen.DebWriter.ControlParagraph()
+		_, err = dgen.addGeneratedRelations(para)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		err = deb.WriteParagraph(&buf, para)
+		controlData = buf.Bytes()
 	}
-	//try template or use a string
-	controlData, err := TemplateFileOrString(filepath.Join(dgen.BuildParams.TemplateDir, "control.tpl"), TemplateBinarydebControl, templateVars)
 	if err != nil {
 		return err
 	}
+	if !isGenerated {
+		controlData, err = dgen.controlWithGeneratedFields(controlData, isResource)
+		if err != nil {
+			return err
+		}
+	}
 	if dgen.BuildParams.IsVerbose {
 		log.Printf("Control file:\n%s", string(controlData))
 	}
-	err = TarAddBytes(tgzw.Writer, controlData, "control", 0644)
+	err = TarAddBytes(dgen.BuildParams.TarWriter(tgzw.Writer), controlData, "control", 0644)
 	return err
 }
+
+// addGeneratedRelations adds the relations needed by generated maintainer script code to a control paragraph:
+// adduser, for the package's system accounts (see SystemAccountsSnippets), and dpkg, for the Maintscripts (see MaintscriptPreDepends).
+// It returns whether any were added.
+// The package itself is not modified.
+func (dgen *DebGenerator) addGeneratedRelations(para *deb.Paragraph) (bool, error) {
+	isChanged := false
+	if dgen.DebWriter.Package.HasSystemAccounts() {
+		depends, _ := para.Get("Depends")
+		value := deb.AddRelation(depends, "adduser")
+		if value != depends {
+			para.Set("Depends", value)
+			isChanged = true
+		}
+	}
+	if len(dgen.Maintscripts) > 0 {
+		preDepends, _ := para.Get("Pre-Depends")
+		value, err := MaintscriptPreDepends(preDepends, dgen.Maintscripts)
+		if err != nil {
+			return false, err
+		}
+		if value != preDepends {
+			para.Set("Pre-Depends", value)
+			isChanged = true
+		}
+	}
+	return isChanged, nil
+}
+
+// controlWithGeneratedFields adds generated relations to a control file from resources or templates (see addGeneratedRelations).
+// A resource file can't know the size of the data archive, so its Installed-Size is also set, unless neither it nor Package.InstalledSize is known.
+// The control file is returned unchanged unless fields are missing or different.
+func (dgen *DebGenerator) controlWithGeneratedFields(controlData []byte, isResource bool) ([]byte, error) {
+	installedSize := ""
+	if isResource {
+		installedSize = dgen.DebWriter.InstalledSizeField()
+	}
+	if len(dgen.Maintscripts) == 0 && !dgen.DebWriter.Package.HasSystemAccounts() && installedSize == "" {
+		return controlData, nil
+	}
+	paras, err := deb.ParseDeb822(bytes.NewReader(controlData))
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing control file: %v", err)
+	}
+	if len(paras) != 1 {
+		return nil, fmt.Errorf("Control file should contain one paragraph, found %d", len(paras))
+	}
+	isChanged, err := dgen.addGeneratedRelations(paras[0])
+	if err != nil {
+		return nil, err
+	}
+	if value, _ := paras[0].Get("Installed-Size"); installedSize != "" && value != installedSize {
+		paras[0].Set("Installed-Size", installedSize)
+		isChanged = true
+	}
+	if !isChanged {
+		return controlData, nil
+	}
+	var buf bytes.Buffer
+	err = deb.WriteParagraph(&buf, paras[0])
+	return buf.Bytes(), err
+}
@@ -0,0 +1,115 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package debgen
+
+import (
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GoBuildParams describes how to cross-compile Go commands for inclusion in a binary package.
+type GoBuildParams struct {
+	Packages   []string // Go package paths of the commands to build, e.g. './cmd/foo'. Resolved relative to BuildParams.WorkingDir
+	IsTrimpath bool     // Whether to build with -trimpath
+	VersionVar string   // Optional. If set, the package version is injected into this variable, e.g. 'main.Version'
+	Ldflags    string   // Optional. Additional flags for the linker
+	GoCmd      string   // The 'go' executable
+}
+
+// NewGoBuildParams is a factory for GoBuildParams.
+func NewGoBuildParams(packages []string) *GoBuildParams {
+	return &GoBuildParams{Packages: packages, IsTrimpath: true, GoCmd: "go"}
+}
+
+// GoExeName returns the executable name which 'go build' produces for a package path.
+func GoExeName(workingDir, pkgPath string) (string, error) {
+	name := path.Base(filepath.ToSlash(pkgPath))
+	if name == "." || name == ".." || name == "/" {
+		abs, err := filepath.Abs(filepath.Join(workingDir, pkgPath))
+		if err != nil {
+			return "", err
+		}
+		name = filepath.Base(abs)
+	}
+	if strings.Contains(name, "...") {
+		return "", fmt.Errorf("Package patterns are not supported: '%s'", pkgPath)
+	}
+	return name, nil
+}
+
+// Args returns the arguments to 'go build' for a single package.
+func (gb *GoBuildParams) Args(pkgPath, output, version string) []string {
+	args := []string{"build", "-o", output}
+	if gb.IsTrimpath {
+		args = append(args, "-trimpath")
+	}
+	ldflags := gb.Ldflags
+	if gb.VersionVar != "" {
+		ldflags = strings.TrimSpace(ldflags + " -X " + gb.VersionVar + "=" + version)
+	}
+	if ldflags != "" {
+		args = append(args, "-ldflags", ldflags)
+	}
+	return append(args, pkgPath)
+}
+
+// GoBuild cross-compiles each Go package for the DebWriter's architecture, with cgo disabled.
+// Executables are written into the temp directory, and added to OrigFiles at deb.ExeDirDefault.
+func (dgen *DebGenerator) GoBuild(gb *GoBuildParams) error {
+	target, err := dgen.DebWriter.GoTarget()
+	if err != nil {
+		return err
+	}
+	outDir, err := filepath.Abs(filepath.Join(dgen.BuildParams.TmpDir, dgen.DebWriter.Package.Name, string(dgen.DebWriter.Architecture)))
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(outDir, 0755)
+	if err != nil {
+		return err
+	}
+	goCmd := gb.GoCmd
+	if goCmd == "" {
+		goCmd = "go"
+	}
+	for _, pkgPath := range gb.Packages {
+		exeName, err := GoExeName(dgen.BuildParams.WorkingDir, pkgPath)
+		if err != nil {
+			return err
+		}
+		output := filepath.Join(outDir, exeName)
+		args := gb.Args(pkgPath, output, dgen.DebWriter.Package.Version)
+		cmd := exec.Command(goCmd, args...)
+		cmd.Dir = dgen.BuildParams.WorkingDir
+		cmd.Env = append(os.Environ(), append(target.Env(), "CGO_ENABLED=0")...)
+		if dgen.BuildParams.IsVerbose {
+			log.Printf("Building %s for %s: %s %v", pkgPath, dgen.DebWriter.Architecture, goCmd, args)
+		}
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("Error building '%s' for %s: %v\n%s", pkgPath, dgen.DebWriter.Architecture, err, out)
+		}
+		dgen.OrigFiles[path.Join(deb.ExeDirDefault, exeName)] = output
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package debgen_test
+
+import (
+	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/debgen"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGoBuildArgs(t *testing.T) {
+	gb := debgen.NewGoBuildParams([]string{"./cmd/foo"})
+	gb.VersionVar = "main.Version"
+	args := gb.Args("./cmd/foo", "out/foo", "1.2-1")
+	expected := []string{"build", "-o", "out/foo", "-trimpath", "-ldflags", "-X main.Version=1.2-1", "./cmd/foo"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected %v, got %v", expected, args)
+	}
+	name, err := debgen.GoExeName(".", "github.com/me/foo")
+	if err != nil || name != "foo" {
+		t.Errorf("Unexpected exe name '%s' (%v)", name, err)
+	}
+	if _, err = debgen.GoExeName(".", "./..."); err == nil {
+		t.Errorf("Expected an error for a package pattern")
+	}
+}
+
+func TestGoBuild(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	workingDir, err := ioutil.TempDir("", "debgen-gobuild")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(workingDir)
+	files := map[string]string{
+		"go.mod":            "module example.com/hello\n",
+		"cmd/hello/main.go": "package main\n\nvar Version string\n\nfunc main() { println(Version) }\n",
+	}
+	for name, content := range files {
+		name = filepath.Join(workingDir, name)
+		if err = os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err = ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	pkg := deb.NewPackage("hello", "0.1", "me <a@me.org>", "Says hello\n")
+	build := debgen.NewBuildParams()
+	build.WorkingDir = workingDir
+	build.TmpDir = filepath.Join(workingDir, "_out", "tmp")
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchArm64), build)
+	gb := debgen.NewGoBuildParams([]string{"./cmd/hello"})
+	gb.VersionVar = "main.Version"
+	err = dgen.GoBuild(gb)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	exe, ok := dgen.OrigFiles["/usr/bin/hello"]
+	if !ok {
+		t.Fatalf("Executable not added to OrigFiles: %v", dgen.OrigFiles)
+	}
+	if _, err = os.Stat(exe); err != nil {
+		t.Errorf("Executable not built: %v", err)
+	}
+
+	dgen = debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAll), build)
+	if err = dgen.GoBuild(gb); err == nil {
+		t.Errorf("Expected an error building for 'all'")
+	}
+}
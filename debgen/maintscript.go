@@ -0,0 +1,279 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package debgen
+
+import (
+	"archive/tar"
+	"bufio"
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"io"
+	"path"
+	"strings"
+)
+
+// MaintscriptCommands are the dpkg-maintscript-helper commands supported by Maintscript, with the dpkg version which introduced each.
+var MaintscriptCommands = map[string]string{
+	"rm_conffile":    "1.15.7.2",
+	"mv_conffile":    "1.15.7.2",
+	"symlink_to_dir": "1.17.14",
+	"dir_to_symlink": "1.17.14",
+}
+
+// MaintscriptScripts are the maintainer scripts which invoke dpkg-maintscript-helper. The helper ignores the actions which don't concern it.
+var MaintscriptScripts = []string{"preinst", "postinst", "prerm", "postrm"}
+
+// Maintscript is a dpkg-maintscript-helper directive, migrating a conffile or path between releases (see DebGenerator.Maintscripts).
+//
+// See dpkg-maintscript-helper(1)
+type Maintscript struct {
+	Command      string // One of MaintscriptCommands
+	Path         string // The conffile (or old conffile, for mv_conffile) or path, e.g. '/etc/foo/foo.conf'
+	Target       string // The new conffile (mv_conffile), old symlink target (symlink_to_dir) or new symlink target (dir_to_symlink). Empty for rm_conffile
+	PriorVersion string // Optional. The operation applies when upgrading from versions before this, e.g. '1.2-1~'
+	Package      string // Optional. Defaults to the package being built
+}
+
+// NewRmConffile is a factory for a Maintscript which removes an obsolete conffile.
+func NewRmConffile(conffile, priorVersion string) *Maintscript {
+	return &Maintscript{Command: "rm_conffile", Path: conffile, PriorVersion: priorVersion}
+}
+
+// NewMvConffile is a factory for a Maintscript which renames a conffile, keeping local changes.
+func NewMvConffile(oldConffile, newConffile, priorVersion string) *Maintscript {
+	return &Maintscript{Command: "mv_conffile", Path: oldConffile, Target: newConffile, PriorVersion: priorVersion}
+}
+
+// NewSymlinkToDir is a factory for a Maintscript which replaces a symlink with a directory.
+func NewSymlinkToDir(pathname, oldTarget, priorVersion string) *Maintscript {
+	return &Maintscript{Command: "symlink_to_dir", Path: pathname, Target: oldTarget, PriorVersion: priorVersion}
+}
+
+// NewDirToSymlink is a factory for a Maintscript which replaces a directory with a symlink.
+func NewDirToSymlink(pathname, newTarget, priorVersion string) *Maintscript {
+	return &Maintscript{Command: "dir_to_symlink", Path: pathname, Target: newTarget, PriorVersion: priorVersion}
+}
+
+// ParseMaintscript parses directives in the format of debhelper's debian/maintscript files, one per line, e.g. 'mv_conffile /etc/foo.conf /etc/foo/foo.conf 1.2~'.
+// Blank lines and comments are ignored.
+func ParseMaintscript(rdr io.Reader) ([]*Maintscript, error) {
+	directives := []*Maintscript{}
+	scanner := bufio.NewScanner(rdr)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		words := strings.Fields(scanner.Text())
+		if len(words) == 0 || strings.HasPrefix(words[0], "#") {
+			continue
+		}
+		if _, ok := MaintscriptCommands[words[0]]; !ok {
+			return nil, fmt.Errorf("Line %d: unsupported maintscript command '%s'", lineNo, words[0])
+		}
+		args := words[1:]
+		directive := &Maintscript{Command: words[0]}
+		if directive.Command != "rm_conffile" {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("Line %d: %s requires a path and a target", lineNo, directive.Command)
+			}
+			directive.Target = args[1]
+			args = append(args[:1], args[2:]...)
+		}
+		if len(args) < 1 || len(args) > 3 {
+			return nil, fmt.Errorf("Line %d: wrong number of arguments for %s", lineNo, directive.Command)
+		}
+		directive.Path = args[0]
+		if len(args) > 1 {
+			directive.PriorVersion = args[1]
+		}
+		if len(args) > 2 {
+			directive.Package = args[2]
+		}
+		directives = append(directives, directive)
+	}
+	return directives, scanner.Err()
+}
+
+// Args returns the arguments to dpkg-maintscript-helper, excluding the maintainer script's arguments.
+func (ms *Maintscript) Args() []string {
+	args := []string{ms.Command, ms.Path}
+	if ms.Target != "" {
+		args = append(args, ms.Target)
+	}
+	if ms.PriorVersion != "" || ms.Package != "" {
+		args = append(args, ms.PriorVersion)
+	}
+	if ms.Package != "" {
+		args = append(args, ms.Package)
+	}
+	return args
+}
+
+// Validate checks the directive's command, paths and prior version.
+// The prior version must not be later than packageVersion, otherwise the operation would be repeated on upgrades to this version.
+func (ms *Maintscript) Validate(packageVersion string) error {
+	if _, ok := MaintscriptCommands[ms.Command]; !ok {
+		return fmt.Errorf("Unsupported maintscript command '%s'", ms.Command)
+	}
+	err := validateDeclaredPath(ms.Command+" path", ms.Path)
+	if err != nil {
+		return err
+	}
+	if ms.Command == "rm_conffile" {
+		if ms.Target != "" {
+			return fmt.Errorf("rm_conffile %s: unexpected target '%s'", ms.Path, ms.Target)
+		}
+	} else if ms.Target == "" || strings.ContainsAny(ms.Target, "'\" \t\n") {
+		return fmt.Errorf("%s %s: invalid target '%s'", ms.Command, ms.Path, ms.Target)
+	}
+	if ms.Command == "mv_conffile" && !path.IsAbs(ms.Target) {
+		return fmt.Errorf("mv_conffile %s: new conffile '%s' should be absolute", ms.Path, ms.Target)
+	}
+	if ms.Package != "" {
+		err = deb.ValidateName(ms.Package)
+		if err != nil {
+			return err
+		}
+	}
+	if ms.PriorVersion != "" {
+		_, _, _, err = deb.ParseVersion(ms.PriorVersion)
+		if err != nil {
+			return fmt.Errorf("%s %s: invalid prior version: %v", ms.Command, ms.Path, err)
+		}
+		isBefore, err := deb.CompareVersions(ms.PriorVersion, "<=", packageVersion)
+		if err != nil {
+			return err
+		}
+		if !isBefore {
+			return fmt.Errorf("%s %s: prior version '%s' is later than the package version '%s'", ms.Command, ms.Path, ms.PriorVersion, packageVersion)
+		}
+	}
+	return nil
+}
+
+// ValidateMaintscripts checks each directive (see Maintscript.Validate), and checks conffile directives against the package's conffiles:
+// a removed conffile must not be shipped, and a renamed conffile's new path must be a conffile.
+// Directory and symlink directives are checked by ValidateMaintscriptEntries.
+func ValidateMaintscripts(directives []*Maintscript, packageVersion string, dataFiles, conffiles []string) error {
+	files := dataFileSet(dataFiles)
+	conffileSet := dataFileSet(conffiles)
+	for _, ms := range directives {
+		err := ms.Validate(packageVersion)
+		if err != nil {
+			return err
+		}
+		switch ms.Command {
+		case "rm_conffile":
+			if files[deb.ConffilePath(ms.Path)] {
+				return fmt.Errorf("rm_conffile %s: the conffile is still in the data archive", ms.Path)
+			}
+		case "mv_conffile":
+			if files[deb.ConffilePath(ms.Path)] {
+				return fmt.Errorf("mv_conffile %s: the old conffile is still in the data archive", ms.Path)
+			}
+			if !conffileSet[deb.ConffilePath(ms.Target)] {
+				return fmt.Errorf("mv_conffile %s: '%s' is not a conffile", ms.Path, ms.Target)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateMaintscriptEntries checks symlink_to_dir and dir_to_symlink directives against the data archive's entries:
+// the path should be a directory or a symlink respectively, as it will be once the package is installed.
+// (ValidateMaintscripts can't check these, as the types of mapped files aren't known until the data archive is built.)
+func ValidateMaintscriptEntries(directives []*Maintscript, entries []*TarEntry) error {
+	types := map[string]byte{}
+	for _, entry := range entries {
+		types[entry.Name] = entry.Type
+	}
+	for _, ms := range directives {
+		typ, exists := types[TarEntryName(ms.Path)]
+		switch ms.Command {
+		case "symlink_to_dir":
+			if !exists || typ != tar.TypeDir {
+				return fmt.Errorf("symlink_to_dir %s: the path should be a directory in the data archive", ms.Path)
+			}
+		case "dir_to_symlink":
+			if !exists || typ != tar.TypeSymlink {
+				return fmt.Errorf("dir_to_symlink %s: the path should be a symlink in the data archive", ms.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// MaintscriptSnippets returns maintainer script snippets which invoke dpkg-maintscript-helper for each directive, in each of MaintscriptScripts.
+func MaintscriptSnippets(directives []*Maintscript) []*ScriptSnippet {
+	snippets := []*ScriptSnippet{}
+	for _, script := range MaintscriptScripts {
+		lines := []string{}
+		for _, ms := range directives {
+			lines = append(lines, "dpkg-maintscript-helper "+shellQuoteAll(ms.Args())+` -- "$@"`)
+		}
+		if len(lines) > 0 {
+			snippets = append(snippets, &ScriptSnippet{Script: script, Provider: "debgo maintscript", Code: strings.Join(lines, "\n")})
+		}
+	}
+	return snippets
+}
+
+// MaintscriptPreDepends adds the dpkg version required by the directives to a Pre-Depends value.
+// Existing dpkg relations are kept; if none of them already require a recent enough dpkg, the requirement is appended.
+func MaintscriptPreDepends(preDepends string, directives []*Maintscript) (string, error) {
+	required := ""
+	for _, ms := range directives {
+		version, ok := MaintscriptCommands[ms.Command]
+		if !ok {
+			return "", fmt.Errorf("Unsupported maintscript command '%s'", ms.Command)
+		}
+		if required == "" {
+			required = version
+		} else if isLater, _ := deb.CompareVersions(version, ">>", required); isLater {
+			required = version
+		}
+	}
+	if required == "" {
+		return preDepends, nil
+	}
+	rels, err := deb.ParseRelations(preDepends)
+	if err != nil {
+		return "", err
+	}
+	for _, alts := range rels {
+		if len(alts) != 1 || alts[0].Name != "dpkg" {
+			continue
+		}
+		if alts[0].Operator == ">=" || alts[0].Operator == ">>" {
+			if satisfied, _ := deb.CompareVersions(alts[0].Version, ">=", required); satisfied {
+				return preDepends, nil
+			}
+		}
+	}
+	relation := "dpkg (>= " + required + ")"
+	if strings.TrimSpace(preDepends) == "" {
+		return relation, nil
+	}
+	return preDepends + ", " + relation, nil
+}
+
+func shellQuoteAll(args []string) string {
+	quoted := []string{}
+	for _, arg := range args {
+		quoted = append(quoted, "'"+arg+"'")
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,180 @@
+package debgen_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/debgen"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMaintscript(t *testing.T) {
+	input := `# migrations
+rm_conffile /etc/foo/old.conf 1.2~
+mv_conffile /etc/foo.conf /etc/foo/foo.conf 1.2~ foo
+
+dir_to_symlink /usr/share/doc/foo foo-common
+`
+	directives, err := debgen.ParseMaintscript(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := [][]string{
+		{"rm_conffile", "/etc/foo/old.conf", "1.2~"},
+		{"mv_conffile", "/etc/foo.conf", "/etc/foo/foo.conf", "1.2~", "foo"},
+		{"dir_to_symlink", "/usr/share/doc/foo", "foo-common"},
+	}
+	if len(directives) != len(expected) {
+		t.Fatalf("Expected %d directives, got %d", len(expected), len(directives))
+	}
+	for i, directive := range directives {
+		if strings.Join(directive.Args(), " ") != strings.Join(expected[i], " ") {
+			t.Errorf("Expected %v, got %v", expected[i], directive.Args())
+		}
+	}
+	for _, bad := range []string{"rm_something /etc/foo", "mv_conffile /etc/foo.conf", "rm_conffile /etc/a 1.0 pkg extra"} {
+		if _, err = debgen.ParseMaintscript(strings.NewReader(bad)); err == nil {
+			t.Errorf("'%s' should be invalid", bad)
+		}
+	}
+}
+
+func TestValidateMaintscripts(t *testing.T) {
+	dataFiles := []string{"./etc/foo/foo.conf", "./usr/bin/foo"}
+	conffiles := []string{"/etc/foo/foo.conf"}
+	valid := []*debgen.Maintscript{
+		debgen.NewRmConffile("/etc/foo/old.conf", "1.2~"),
+		debgen.NewMvConffile("/etc/foo.conf", "/etc/foo/foo.conf", "1.2-1~"),
+		debgen.NewSymlinkToDir("/usr/share/foo", "/usr/share/bar", ""),
+	}
+	if err := debgen.ValidateMaintscripts(valid, "1.2-1", dataFiles, conffiles); err != nil {
+		t.Errorf("%v", err)
+	}
+	invalid := []*debgen.Maintscript{
+		debgen.NewRmConffile("/etc/foo/foo.conf", "1.2~"),
+		debgen.NewRmConffile("/etc/foo/old.conf", "1.3"),
+		debgen.NewRmConffile("/etc/foo/old.conf", "not a version"),
+		debgen.NewRmConffile("etc/foo/old.conf", ""),
+		debgen.NewMvConffile("/etc/foo.conf", "/usr/bin/foo", "1.2~"),
+		debgen.NewDirToSymlink("/usr/share/foo", "", "1.2~"),
+		{Command: "rm_dir", Path: "/usr/share/foo"},
+	}
+	for _, ms := range invalid {
+		if err := debgen.ValidateMaintscripts([]*debgen.Maintscript{ms}, "1.2-1", dataFiles, conffiles); err == nil {
+			t.Errorf("%v should be invalid", ms.Args())
+		}
+	}
+}
+
+func TestMaintscriptPreDepends(t *testing.T) {
+	conffileOnly := []*debgen.Maintscript{debgen.NewRmConffile("/etc/foo.conf", "")}
+	both := append(conffileOnly, debgen.NewDirToSymlink("/usr/share/doc/foo", "bar", ""))
+	tests := []struct {
+		preDepends string
+		directives []*debgen.Maintscript
+		expected   string
+	}{
+		{"", nil, ""},
+		{"", conffileOnly, "dpkg (>= 1.15.7.2)"},
+		{"libc6", both, "libc6, dpkg (>= 1.17.14)"},
+		{"dpkg (>= 1.18)", both, "dpkg (>= 1.18)"},
+		{"dpkg (>= 1.16)", both, "dpkg (>= 1.16), dpkg (>= 1.17.14)"},
+	}
+	for _, test := range tests {
+		value, err := debgen.MaintscriptPreDepends(test.preDepends, test.directives)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if value != test.expected {
+			t.Errorf("Expected '%s', got '%s'", test.expected, value)
+		}
+	}
+}
+
+func TestValidateMaintscriptEntries(t *testing.T) {
+	entries := []*debgen.TarEntry{
+		debgen.NewTarEntry("/usr/share/foo", tar.TypeDir, 0755, time.Now()),
+		debgen.NewTarEntry("/usr/share/bar", tar.TypeSymlink, 0777, time.Now()),
+	}
+	valid := []*debgen.Maintscript{
+		debgen.NewSymlinkToDir("/usr/share/foo", "/usr/share/baz", ""),
+		debgen.NewDirToSymlink("/usr/share/bar", "/usr/share/foo", ""),
+		debgen.NewRmConffile("/etc/foo/old.conf", ""),
+	}
+	if err := debgen.ValidateMaintscriptEntries(valid, entries); err != nil {
+		t.Errorf("%v", err)
+	}
+	invalid := []*debgen.Maintscript{
+		debgen.NewSymlinkToDir("/usr/share/bar", "/usr/share/baz", ""),
+		debgen.NewDirToSymlink("/usr/share/foo", "/usr/share/bar", ""),
+		debgen.NewSymlinkToDir("/usr/share/baz", "/usr/share/foo", ""),
+	}
+	for _, ms := range invalid {
+		if err := debgen.ValidateMaintscriptEntries([]*debgen.Maintscript{ms}, entries); err == nil {
+			t.Errorf("%v should be invalid", ms.Args())
+		}
+	}
+}
+
+func TestGenMaintscripts(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "debgen-maintscript")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	conf := filepath.Join(tmpDir, "foo.conf")
+	if err = ioutil.WriteFile(conf, []byte("a=b\n"), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg := deb.NewPackage("testpkg", "1.2-1", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	build.TmpDir = tmpDir
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+	dgen.OrigFiles["/etc/testpkg/foo.conf"] = conf
+	dgen.Maintscripts = []*debgen.Maintscript{debgen.NewMvConffile("/etc/foo.conf", "/etc/testpkg/foo.conf", "1.2-1~")}
+	buf := new(bytes.Buffer)
+	if err = dgen.GenerateTo(buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, name := range debgen.MaintscriptScripts {
+		script := new(bytes.Buffer)
+		err = deb.DebExtractFileL2(bytes.NewReader(buf.Bytes()), deb.BinaryControlArchiveNameDefault, name, script)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		expected := `dpkg-maintscript-helper 'mv_conffile' '/etc/foo.conf' '/etc/testpkg/foo.conf' '1.2-1~' -- "$@"`
+		if !strings.Contains(script.String(), expected) {
+			t.Errorf("%s should contain '%s', got:\n%s", name, expected, script.String())
+		}
+	}
+	control := new(bytes.Buffer)
+	err = deb.DebExtractFileL2(bytes.NewReader(buf.Bytes()), deb.BinaryControlArchiveNameDefault, "control", control)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !strings.Contains(control.String(), "Pre-Depends: dpkg (>= 1.15.7.2)\n") {
+		t.Errorf("control should pre-depend on dpkg, got:\n%s", control.String())
+	}
+	if pkg.PreDepends != "" {
+		t.Errorf("The package should not be modified, got Pre-Depends '%s'", pkg.PreDepends)
+	}
+	// templated control files need the relation too
+	dgen.DefaultTemplateStrings["control"] = debgen.TemplateBinarydebControl
+	buf.Reset()
+	if err = dgen.GenerateTo(buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	control.Reset()
+	err = deb.DebExtractFileL2(bytes.NewReader(buf.Bytes()), deb.BinaryControlArchiveNameDefault, "control", control)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !strings.Contains(control.String(), "Pre-Depends: dpkg (>= 1.15.7.2)\n") || !strings.Contains(control.String(), "Description: Dummy package for doing nothing\n") {
+		t.Errorf("templated control should pre-depend on dpkg, got:\n%s", control.String())
+	}
+}
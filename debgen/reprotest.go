@@ -0,0 +1,323 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package debgen
+
+import (
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReprotestParams configures a reproducibility test, in which a package is built twice and the results compared.
+type ReprotestParams struct {
+	SourceDir    string // Directory copied into each build's working directory (e.g. containing sources, templates and resources). Optional
+	ArtifactsDir string // Directory containing the artifacts to compare, relative to each build's working directory. Default deb.DistDirDefault
+	TmpDir       string // Parent directory for the builds' working directories. Defaults to the system's temp directory
+	IsKeepBuilds bool   // Whether to keep the builds' working directories afterwards
+
+	IsVaryTime       bool // Build in different timezones, at different times
+	IsVaryUmask      bool // Build with different umasks (on platforms which support them)
+	IsVaryWorkingDir bool // Build in differently-named working directories, at different depths
+	IsVaryLocale     bool // Build with different LANG, LC_ALL and LANGUAGE
+}
+
+// ReprotestFunc builds the package in workingDir, which is also the current directory during the build.
+type ReprotestFunc func(workingDir string) error
+
+// reprotestVariation describes the environment for one build
+type reprotestVariation struct {
+	workingDir string
+	env        map[string]string
+	location   *time.Location
+	umask      int
+}
+
+// NewReprotestParams is a factory for ReprotestParams, varying everything supported
+func NewReprotestParams() *ReprotestParams {
+	return &ReprotestParams{
+		ArtifactsDir:     deb.DistDirDefault,
+		IsVaryTime:       true,
+		IsVaryUmask:      true,
+		IsVaryWorkingDir: true,
+		IsVaryLocale:     true,
+	}
+}
+
+// Reprotest runs build twice, each time in an isolated working directory, then compares the artifacts structurally (see deb.DiffFiles).
+//
+// The environment is varied between the builds as configured. Variations apply to the whole process (and are inherited by any commands run),
+// and are reverted after each build, so Reprotest must not be run concurrently with anything sensitive to the environment.
+func Reprotest(rp *ReprotestParams, build ReprotestFunc) ([]deb.Difference, error) {
+	root, err := ioutil.TempDir(rp.TmpDir, "debgen-reprotest")
+	if err != nil {
+		return nil, err
+	}
+	// builds change directory, so relative paths won't do
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if !rp.IsKeepBuilds {
+		defer os.RemoveAll(root)
+	}
+	variations := rp.variations(root)
+	for i, variation := range variations {
+		if i > 0 && rp.IsVaryTime {
+			// ensure the clock has moved on, at the granularity of archive timestamps
+			time.Sleep(time.Now().Truncate(time.Second).Add(time.Second).Sub(time.Now()))
+		}
+		err = rp.run(variation, build)
+		if err != nil {
+			return nil, fmt.Errorf("Error running build %d: %v", i+1, err)
+		}
+	}
+	return DiffDirs(filepath.Join(variations[0].workingDir, rp.ArtifactsDir), filepath.Join(variations[1].workingDir, rp.ArtifactsDir))
+}
+
+func (rp *ReprotestParams) variations(root string) []*reprotestVariation {
+	control := &reprotestVariation{workingDir: filepath.Join(root, "a", "build"), env: map[string]string{}, umask: -1}
+	varied := &reprotestVariation{workingDir: filepath.Join(root, "b", "build"), env: map[string]string{}, umask: -1}
+	if rp.IsVaryTime {
+		control.env["TZ"] = "UTC"
+		control.location = time.UTC
+		varied.env["TZ"] = "<+14>-14"
+		varied.location = time.FixedZone("+14", 14*60*60)
+	}
+	if rp.IsVaryUmask {
+		control.umask = 0022
+		varied.umask = 0002
+	}
+	if rp.IsVaryWorkingDir {
+		varied.workingDir = filepath.Join(root, "b", "varied", "build-varied")
+	}
+	if rp.IsVaryLocale {
+		control.env["LANG"] = "C.UTF-8"
+		control.env["LC_ALL"] = "C.UTF-8"
+		control.env["LANGUAGE"] = "en_US:en"
+		varied.env["LANG"] = "fr_CH.UTF-8"
+		varied.env["LC_ALL"] = "fr_CH.UTF-8"
+		varied.env["LANGUAGE"] = "fr_CH:fr"
+	}
+	return []*reprotestVariation{control, varied}
+}
+
+func (rp *ReprotestParams) run(variation *reprotestVariation, build ReprotestFunc) error {
+	restore, err := variation.apply()
+	defer restore()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(variation.workingDir, 0755)
+	if err != nil {
+		return err
+	}
+	if rp.SourceDir != "" {
+		exclude := strings.Split(filepath.ToSlash(filepath.Clean(rp.ArtifactsDir)), "/")[0]
+		err = copyDir(rp.SourceDir, variation.workingDir, exclude)
+		if err != nil {
+			return fmt.Errorf("Error copying sources: %v", err)
+		}
+	}
+	err = os.Chdir(variation.workingDir)
+	if err != nil {
+		return err
+	}
+	return build(variation.workingDir)
+}
+
+// apply applies the variation to the process, returning a func to revert it
+func (variation *reprotestVariation) apply() (func(), error) {
+	restores := []func(){}
+	restore := func() {
+		for i := len(restores) - 1; i >= 0; i-- {
+			restores[i]()
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return restore, err
+	}
+	restores = append(restores, func() { os.Chdir(wd) })
+	for key, value := range variation.env {
+		original, isSet := os.LookupEnv(key)
+		restores = append(restores, func(key string) func() {
+			return func() {
+				if isSet {
+					os.Setenv(key, original)
+				} else {
+					os.Unsetenv(key)
+				}
+			}
+		}(key))
+		os.Setenv(key, value)
+	}
+	if variation.location != nil {
+		local := time.Local
+		restores = append(restores, func() { time.Local = local })
+		time.Local = variation.location
+	}
+	if variation.umask >= 0 {
+		original := setUmask(variation.umask)
+		restores = append(restores, func() { setUmask(original) })
+	}
+	return restore, nil
+}
+
+// ReprotestCommand returns a ReprotestFunc which runs a command in each build's working directory.
+// The command's output is written to stderr.
+func ReprotestCommand(name string, args ...string) ReprotestFunc {
+	return func(workingDir string) error {
+		cmd := exec.Command(name, args...)
+		cmd.Dir = workingDir
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+}
+
+// DiffDirs compares the files in two directories, by relative path (see deb.DiffFiles)
+func DiffDirs(dirA, dirB string) ([]deb.Difference, error) {
+	filesA, err := listFiles(dirA)
+	if err != nil {
+		return nil, err
+	}
+	filesB, err := listFiles(dirB)
+	if err != nil {
+		return nil, err
+	}
+	all := []string{}
+	for name := range filesA {
+		all = append(all, name)
+	}
+	for name := range filesB {
+		if !filesA[name] {
+			all = append(all, name)
+		}
+	}
+	sort.Strings(all)
+	diffs := []deb.Difference{}
+	for _, name := range all {
+		if !filesB[name] {
+			diffs = append(diffs, deb.Difference{Path: name, Field: "presence", A: "present", B: "absent"})
+			continue
+		}
+		if !filesA[name] {
+			diffs = append(diffs, deb.Difference{Path: name, Field: "presence", A: "absent", B: "present"})
+			continue
+		}
+		fileDiffs, err := diffFiles(name, filepath.Join(dirA, name), filepath.Join(dirB, name))
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, fileDiffs...)
+	}
+	return diffs, nil
+}
+
+func diffFiles(name, pathA, pathB string) ([]deb.Difference, error) {
+	a, err := os.Open(pathA)
+	if err != nil {
+		return nil, err
+	}
+	defer a.Close()
+	b, err := os.Open(pathB)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+	return deb.DiffFiles(filepath.ToSlash(name), a, b)
+}
+
+// listFiles lists regular files below dir, by relative path
+func listFiles(dir string) (map[string]bool, error) {
+	files := map[string]bool{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files[rel] = true
+		}
+		return nil
+	})
+	return files, err
+}
+
+// copyDir copies the contents of src into dest, excluding the named top-level entry. File permissions are subject to the umask.
+func copyDir(src, dest, exclude string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if rel == exclude {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dest, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			linkname, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(linkname, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dest string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,47 @@
+package debgen_test
+
+import (
+	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/debgen"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReprotest(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "debgen-reprotest-src")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(srcDir)
+	if err = ioutil.WriteFile(filepath.Join(srcDir, "a"), []byte("a"), 0775); err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	for _, isReproducible := range []bool{true, false} {
+		rp := debgen.NewReprotestParams()
+		rp.SourceDir = srcDir
+		diffs, err := debgen.Reprotest(rp, func(workingDir string) error {
+			build := debgen.NewBuildParams()
+			build.IsReproducible = isReproducible
+			build.SourceDateEpoch = time.Unix(1500000000, 0).UTC()
+			if err := build.Init(); err != nil {
+				return err
+			}
+			dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+			dgen.OrigFiles["/usr/bin/a"] = "a"
+			return dgen.GenerateAllDefault()
+		})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if isReproducible && len(diffs) > 0 {
+			t.Errorf("Expected no differences, got %v", diffs)
+		}
+		if !isReproducible && len(diffs) == 0 {
+			t.Errorf("Expected differences between non-reproducible builds")
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package debgen
+
+import (
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"sort"
+	"strings"
+)
+
+// DebhelperToken is replaced with the snippets in user-supplied maintainer scripts
+const DebhelperToken = "#DEBHELPER#"
+
+// ScriptSnippet is a fragment of shell, contributed to a maintainer script
+type ScriptSnippet struct {
+	Script   string   // Maintainer script, e.g. 'postinst'
+	Actions  []string // Actions for which the snippet runs, e.g. 'configure' (see deb.MaintainerScriptActions). Empty means all
+	Priority int      // Snippets with lower priorities are added first. Default 0
+	Provider string   // Who contributed the snippet, e.g. 'systemd'. Written into a comment
+	Code     string   // Shell code
+}
+
+// ScriptSnippets is a registry of snippets, to which multiple providers can contribute.
+type ScriptSnippets struct {
+	snippets []*ScriptSnippet
+}
+
+// NewScriptSnippets is a factory for ScriptSnippets
+func NewScriptSnippets() *ScriptSnippets {
+	return &ScriptSnippets{snippets: []*ScriptSnippet{}}
+}
+
+// Add registers a snippet, checking the script and actions.
+func (ss *ScriptSnippets) Add(snippet *ScriptSnippet) error {
+	actions, ok := deb.MaintainerScriptActions[snippet.Script]
+	if !ok {
+		return fmt.Errorf("Unsupported maintainer script '%s'", snippet.Script)
+	}
+	for _, action := range snippet.Actions {
+		isValid := false
+		for _, valid := range actions {
+			if action == valid {
+				isValid = true
+			}
+		}
+		if !isValid {
+			return fmt.Errorf("Unsupported action '%s' for %s", action, snippet.Script)
+		}
+	}
+	ss.snippets = append(ss.snippets, snippet)
+	return nil
+}
+
+// Append registers code to run in script, for the given actions (or all actions if there are none)
+func (ss *ScriptSnippets) Append(provider, script, code string, actions ...string) error {
+	return ss.Add(&ScriptSnippet{Script: script, Actions: actions, Provider: provider, Code: code})
+}
+
+// ForScript returns a script's snippets, in order.
+// Snippets are ordered by priority, then by when they were added.
+// As with debhelper, the order is reversed for prerm and postrm, so that removal undoes installation in reverse.
+func (ss *ScriptSnippets) ForScript(script string) []*ScriptSnippet {
+	ret := []*ScriptSnippet{}
+	if ss == nil {
+		return ret
+	}
+	for _, snippet := range ss.snippets {
+		if snippet.Script == script {
+			ret = append(ret, snippet)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Priority < ret[j].Priority })
+	if script == "prerm" || script == "postrm" {
+		for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+			ret[i], ret[j] = ret[j], ret[i]
+		}
+	}
+	return ret
+}
+
+// Render returns a script's snippets as shell code, each in a commented section and wrapped in a test of the action if necessary.
+// Returns an empty string if there are no snippets.
+func (ss *ScriptSnippets) Render(script string) string {
+	sections := []string{}
+	for _, snippet := range ss.ForScript(script) {
+		code := strings.TrimRight(snippet.Code, "\n")
+		if len(snippet.Actions) > 0 {
+			tests := []string{}
+			for _, action := range snippet.Actions {
+				tests = append(tests, fmt.Sprintf(`[ "$1" = "%s" ]`, action))
+			}
+			lines := strings.Split(code, "\n")
+			for i, line := range lines {
+				if line != "" {
+					lines[i] = "\t" + line
+				}
+			}
+			code = "if " + strings.Join(tests, " || ") + "; then\n" + strings.Join(lines, "\n") + "\nfi"
+		}
+		sections = append(sections, fmt.Sprintf("# Automatically added by %s\n%s\n# End automatically added section", snippet.Provider, code))
+	}
+	return strings.Join(sections, "\n")
+}
+
+// Apply adds the snippets to a maintainer script.
+// If script is nil, a complete 'set -e' script is generated (or nil returned, if there are no snippets).
+// Otherwise, DebhelperToken is replaced with the snippets. As with debhelper, snippets are omitted from scripts without the token.
+func (ss *ScriptSnippets) Apply(name string, script []byte) []byte {
+	snippets := ss.Render(name)
+	if script == nil {
+		if snippets == "" {
+			return nil
+		}
+		return []byte("#!/bin/sh\nset -e\n\n" + snippets + "\n")
+	}
+	return []byte(strings.Replace(string(script), DebhelperToken, snippets, -1))
+}
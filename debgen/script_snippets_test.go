@@ -0,0 +1,97 @@
+package debgen_test
+
+import (
+	"bytes"
+	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/debgen"
+	"testing"
+)
+
+func TestScriptSnippets(t *testing.T) {
+	ss := debgen.NewScriptSnippets()
+	if err := ss.Append("a", "postinst", "echo a", "configure"); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := ss.Add(&debgen.ScriptSnippet{Script: "postinst", Provider: "b", Code: "echo b\necho bb", Priority: -1}); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := ss.Append("c", "postinst", "echo c", "configure", "abort-upgrade"); err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := `# Automatically added by b
+echo b
+echo bb
+# End automatically added section
+# Automatically added by a
+if [ "$1" = "configure" ]; then
+	echo a
+fi
+# End automatically added section
+# Automatically added by c
+if [ "$1" = "configure" ] || [ "$1" = "abort-upgrade" ]; then
+	echo c
+fi
+# End automatically added section`
+	if actual := ss.Render("postinst"); actual != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	ss.Append("a", "postrm", "echo a")
+	ss.Append("b", "postrm", "echo b")
+	snippets := ss.ForScript("postrm")
+	if len(snippets) != 2 || snippets[0].Provider != "b" {
+		t.Errorf("postrm snippets should be in reverse order")
+	}
+
+	if err := ss.Append("a", "postinst", "echo a", "purge"); err == nil {
+		t.Errorf("purge is not a postinst action")
+	}
+	if err := ss.Append("a", "config", "echo a"); err == nil {
+		t.Errorf("config is not a maintainer script")
+	}
+}
+
+func TestScriptSnippetsApply(t *testing.T) {
+	ss := debgen.NewScriptSnippets()
+	if ss.Apply("postinst", nil) != nil {
+		t.Errorf("No script should be generated without snippets")
+	}
+	if actual := string(ss.Apply("postinst", []byte("#!/bin/sh\n#DEBHELPER#\nexit 0\n"))); actual != "#!/bin/sh\n\nexit 0\n" {
+		t.Errorf("Token should be removed, got:\n%s", actual)
+	}
+	ss.Append("a", "postinst", "echo a")
+	expected := "#!/bin/sh\nset -e\n\n# Automatically added by a\necho a\n# End automatically added section\n"
+	if actual := string(ss.Apply("postinst", nil)); actual != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+	expected = "#!/bin/sh\necho before\n# Automatically added by a\necho a\n# End automatically added section\nexit 0\n"
+	if actual := string(ss.Apply("postinst", []byte("#!/bin/sh\necho before\n#DEBHELPER#\nexit 0\n"))); actual != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestGenScriptSnippets(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+	dgen.ControlFiles["postinst"] = []byte("#!/bin/sh\nset -e\n#DEBHELPER#\n")
+	dgen.Snippets.Append("test", "postinst", "echo postinst", "configure")
+	dgen.Snippets.Append("test", "prerm", "echo prerm")
+	buf := new(bytes.Buffer)
+	if err := dgen.GenerateTo(buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	for name, expected := range map[string]string{
+		"postinst": "#!/bin/sh\nset -e\n# Automatically added by test\nif [ \"$1\" = \"configure\" ]; then\n\techo postinst\nfi\n# End automatically added section\n",
+		"prerm":    "#!/bin/sh\nset -e\n\n# Automatically added by test\necho prerm\n# End automatically added section\n",
+	} {
+		script := new(bytes.Buffer)
+		err := deb.DebExtractFileL2(bytes.NewReader(buf.Bytes()), deb.BinaryControlArchiveNameDefault, name, script)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if script.String() != expected {
+			t.Errorf("Expected %s:\n%s\ngot:\n%s", name, expected, script.String())
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -85,11 +86,11 @@ func (spgen *SourcePackageGenerator) GenOrigArchive() error {
 	//TODO add/exclude resources to /usr/share
 	origFilePath := filepath.Join(spgen.BuildParams.DestDir, spgen.SourcePackage.OrigFileName)
 	tgzw, err := targz.NewWriterFromFile(origFilePath)
-	defer tgzw.Close()
 	if err != nil {
 		return err
 	}
-	err = TarAddFiles(tgzw.Writer, spgen.OrigFiles)
+	defer tgzw.Close()
+	err = TarAddFiles(spgen.BuildParams.TarWriter(tgzw.Writer), spgen.OrigFiles)
 	if err != nil {
 		return err
 	}
@@ -107,21 +108,31 @@ func (spgen *SourcePackageGenerator) GenOrigArchive() error {
 // This contains all the control data, changelog, rules, etc
 func (spgen *SourcePackageGenerator) GenDebianArchive() error {
 	//set up template
-	templateVars := NewTemplateData(spgen.SourcePackage.Package)
+	templateVars := NewTemplateDataAt(spgen.SourcePackage.Package, spgen.BuildParams.Timestamp())
 
 	// generate .debian.tar.gz (just containing debian/ directory)
 	tgzw, err := targz.NewWriterFromFile(filepath.Join(spgen.BuildParams.DestDir, spgen.SourcePackage.DebianFileName))
+	if err != nil {
+		return err
+	}
 	defer tgzw.Close()
+	tw := spgen.BuildParams.TarWriter(tgzw.Writer)
 	resourceDir := filepath.Join(spgen.BuildParams.ResourcesDir, "source", DebianDir)
 	templateDir := filepath.Join(spgen.BuildParams.TemplateDir, "source", DebianDir)
 
 	//TODO change this to iterate over specified list of files.
-	for debianFile, defaultTemplateStr := range spgen.TemplateStrings {
+	debianFiles := []string{}
+	for debianFile := range spgen.TemplateStrings {
+		debianFiles = append(debianFiles, debianFile)
+	}
+	sort.Strings(debianFiles)
+	for _, debianFile := range debianFiles {
+		defaultTemplateStr := spgen.TemplateStrings[debianFile]
 		debianFilePath := strings.Replace(debianFile, "/", string(os.PathSeparator), -1) //fixing source/options, source/format for local files
 		resourcePath := filepath.Join(resourceDir, debianFilePath)
 		_, err = os.Stat(resourcePath)
 		if err == nil {
-			err = TarAddFile(tgzw.Writer, resourcePath, debianFile)
+			err = TarAddFile(tw, resourcePath, debianFile)
 			if err != nil {
 				return err
 			}
@@ -130,7 +141,7 @@ func (spgen *SourcePackageGenerator) GenDebianArchive() error {
 			if err != nil {
 				return err
 			}
-			err = TarAddBytes(tgzw.Writer, controlData, DebianDir+"/"+debianFile, int64(0644))
+			err = TarAddBytes(tw, controlData, DebianDir+"/"+debianFile, int64(0644))
 			if err != nil {
 				return err
 			}
@@ -142,7 +153,7 @@ func (spgen *SourcePackageGenerator) GenDebianArchive() error {
 		resourcePath := filepath.Join(spgen.BuildParams.ResourcesDir, DebianDir, scriptName)
 		_, err = os.Stat(resourcePath)
 		if err == nil {
-			err = TarAddFile(tgzw.Writer, resourcePath, scriptName)
+			err = TarAddFile(tw, resourcePath, scriptName)
 			if err != nil {
 				return err
 			}
@@ -155,7 +166,7 @@ func (spgen *SourcePackageGenerator) GenDebianArchive() error {
 				if err != nil {
 					return err
 				}
-				err = TarAddBytes(tgzw.Writer, scriptData, scriptName, 0755)
+				err = TarAddBytes(tw, scriptData, scriptName, 0755)
 				if err != nil {
 					return err
 				}
@@ -176,7 +187,7 @@ func (spgen *SourcePackageGenerator) GenDebianArchive() error {
 
 func (spgen *SourcePackageGenerator) GenDscFile() error {
 	//set up template
-	templateVars := NewTemplateData(spgen.SourcePackage.Package)
+	templateVars := NewTemplateDataAt(spgen.SourcePackage.Package, spgen.BuildParams.Timestamp())
 	//4. Create dsc file (calculate checksums first)
 	cs := new(deb.Checksums)
 	err := cs.Add(filepath.Join(spgen.BuildParams.DestDir, spgen.SourcePackage.OrigFileName), spgen.SourcePackage.OrigFileName)
@@ -1,9 +1,16 @@
 package debgen_test
 
 import (
+	"bytes"
 	"github.com/laher/debgo-v0.2/deb"
 	"github.com/laher/debgo-v0.2/debgen"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
 )
 
 func Example_genSourcePackage() {
@@ -35,3 +42,52 @@ func Example_genSourcePackage() {
 	// Output:
 	//
 }
+
+func TestGenSourcePackageReproducible(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "debgen-reproducible-src")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(srcDir)
+	src := filepath.Join(srcDir, "main.go")
+	if err = ioutil.WriteFile(src, []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	debgen.ApplyGoDefaults(pkg)
+	outputs := []map[string][]byte{}
+	for i := 0; i < 2; i++ {
+		mtime := time.Now().Add(time.Duration(i) * time.Hour)
+		if err = os.Chtimes(src, mtime, mtime); err != nil {
+			t.Fatalf("%v", err)
+		}
+		build := debgen.NewBuildParams()
+		build.IsReproducible = true
+		build.SourceDateEpoch = time.Unix(1500000000, 0).UTC()
+		build.DestDir = filepath.Join(srcDir, "dist", strconv.Itoa(i))
+		build.TmpDir = filepath.Join(srcDir, "tmp")
+		if err = build.Init(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		spkg := deb.NewSourcePackage(pkg)
+		spgen := debgen.NewSourcePackageGenerator(spkg, build)
+		spgen.ApplyDefaultsPureGo()
+		spgen.OrigFiles = map[string]string{"testpkg-0.0.2/main.go": src}
+		if err = spgen.GenerateAllDefault(); err != nil {
+			t.Fatalf("%v", err)
+		}
+		output := map[string][]byte{}
+		for _, filename := range []string{spkg.OrigFileName, spkg.DebianFileName, spkg.DscFileName} {
+			output[filename], err = ioutil.ReadFile(filepath.Join(build.DestDir, filename))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+		outputs = append(outputs, output)
+	}
+	for filename, data := range outputs[0] {
+		if !bytes.Equal(data, outputs[1][filename]) {
+			t.Errorf("Reproducible builds of %s differ", filename)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package debgen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"strings"
+)
+
+// Directories for systemd's configuration of system accounts and directories
+const (
+	SysusersDirDefault = "/usr/lib/sysusers.d"
+	TmpfilesDirDefault = "/usr/lib/tmpfiles.d"
+)
+
+// SystemAccountsSnippetPriority orders system account snippets before others (e.g. systemd's), so that services can run as the new users
+const SystemAccountsSnippetPriority = -100
+
+// SystemAccountsSnippets returns maintainer script snippets which create the package's system groups and users (see deb.Package.SystemUsers) using adduser.
+// The snippets are idempotent. Accounts marked IsRemoveOnPurge are deleted on purge, along with the group of the same name created for a user.
+func SystemAccountsSnippets(pkg *deb.Package) []*ScriptSnippet {
+	provider := "debgo system accounts"
+	create := []string{}
+	remove := []string{}
+	for _, group := range pkg.SystemGroups {
+		create = append(create, addGroupCode(group.Name))
+		if group.IsRemoveOnPurge {
+			remove = append(remove, delGroupCode(group.Name))
+		}
+	}
+	for _, user := range pkg.SystemUsers {
+		args := []string{"--system", "--shell '" + shellOrDefault(user.Shell) + "'"}
+		if user.HasHome() {
+			args = append(args, "--home '"+user.Home+"'")
+		} else {
+			args = append(args, "--home '"+deb.SystemUserHomeDefault+"'", "--no-create-home")
+		}
+		if user.PrimaryGroup() == user.Name {
+			args = append(args, "--group")
+		} else {
+			create = append(create, addGroupCode(user.PrimaryGroup()))
+			args = append(args, "--ingroup '"+user.PrimaryGroup()+"'")
+		}
+		if user.Gecos != "" {
+			args = append(args, "--gecos '"+user.Gecos+"'")
+		}
+		create = append(create, fmt.Sprintf(`if ! getent passwd '%s' >/dev/null; then
+	adduser %s '%s' >/dev/null
+fi`, user.Name, strings.Join(args, " "), user.Name))
+		for _, group := range user.Groups {
+			create = append(create, fmt.Sprintf(`if ! id -nG '%s' | tr ' ' '\n' | grep -qxF '%s'; then
+	adduser '%s' '%s' >/dev/null
+fi`, user.Name, group, user.Name, group))
+		}
+		if user.IsRemoveOnPurge {
+			removeUser := []string{fmt.Sprintf(`if getent passwd '%s' >/dev/null && command -v deluser >/dev/null; then
+	deluser --system '%s' >/dev/null || true
+fi`, user.Name, user.Name)}
+			if user.PrimaryGroup() == user.Name {
+				// the group created by 'adduser --group'
+				removeUser = append(removeUser, delGroupCode(user.Name))
+			}
+			remove = append(removeUser, remove...)
+		}
+	}
+	snippets := []*ScriptSnippet{}
+	if len(create) > 0 {
+		snippets = append(snippets, &ScriptSnippet{Script: "postinst", Actions: []string{"configure"}, Priority: SystemAccountsSnippetPriority,
+			Provider: provider, Code: strings.Join(create, "\n")})
+	}
+	if len(remove) > 0 {
+		snippets = append(snippets, &ScriptSnippet{Script: "postrm", Actions: []string{"purge"}, Priority: SystemAccountsSnippetPriority,
+			Provider: provider, Code: strings.Join(remove, "\n")})
+	}
+	return snippets
+}
+
+func addGroupCode(name string) string {
+	return fmt.Sprintf(`if ! getent group '%s' >/dev/null; then
+	addgroup --system '%s' >/dev/null
+fi`, name, name)
+}
+
+func delGroupCode(name string) string {
+	return fmt.Sprintf(`if getent group '%s' >/dev/null && command -v delgroup >/dev/null; then
+	delgroup --system '%s' >/dev/null || true
+fi`, name, name)
+}
+
+func shellOrDefault(shell string) string {
+	if shell == "" {
+		return deb.SystemUserShellDefault
+	}
+	return shell
+}
+
+// SysusersConf generates a sysusers.d configuration for the package's system groups and users.
+// Returns nil if there are none.
+func SysusersConf(pkg *deb.Package) []byte {
+	if !pkg.HasSystemAccounts() {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, group := range pkg.SystemGroups {
+		fmt.Fprintf(&buf, "g %s -\n", group.Name)
+	}
+	for _, user := range pkg.SystemUsers {
+		id := "-"
+		if user.PrimaryGroup() != user.Name {
+			fmt.Fprintf(&buf, "g %s -\n", user.PrimaryGroup())
+			id = "-:" + user.PrimaryGroup()
+		}
+		gecos := "-"
+		if user.Gecos != "" {
+			gecos = `"` + user.Gecos + `"`
+		}
+		home := user.Home
+		if home == "" {
+			home = deb.SystemUserHomeDefault
+		}
+		fmt.Fprintf(&buf, "u %s %s %s %s %s\n", user.Name, id, gecos, home, shellOrDefault(user.Shell))
+		for _, group := range user.Groups {
+			fmt.Fprintf(&buf, "m %s %s\n", user.Name, group)
+		}
+	}
+	return buf.Bytes()
+}
+
+// TmpfilesConf generates a tmpfiles.d configuration, creating the home directories of the package's system users.
+// Returns nil if there are none.
+func TmpfilesConf(pkg *deb.Package) []byte {
+	var buf bytes.Buffer
+	for _, user := range pkg.SystemUsers {
+		if user.HasHome() {
+			fmt.Fprintf(&buf, "d %s 0755 %s %s -\n", user.Home, user.Name, user.PrimaryGroup())
+		}
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,135 @@
+package debgen_test
+
+import (
+	"bytes"
+	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/debgen"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSystemAccountsSnippets(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me", "desc")
+	group := deb.NewSystemGroup("testgrp")
+	group.IsRemoveOnPurge = true
+	user := deb.NewSystemUser("testpkg")
+	user.Home = "/var/lib/testpkg"
+	user.Groups = []string{"testgrp"}
+	user.IsRemoveOnPurge = true
+	pkg.SystemGroups = []*deb.SystemGroup{group}
+	pkg.SystemUsers = []*deb.SystemUser{user}
+	ss := debgen.NewScriptSnippets()
+	for _, snippet := range debgen.SystemAccountsSnippets(pkg) {
+		if err := ss.Add(snippet); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	postinst := ss.Render("postinst")
+	for _, fragment := range []string{
+		"if ! getent group 'testgrp' >/dev/null; then\n\t\taddgroup --system 'testgrp' >/dev/null",
+		"adduser --system --shell '/usr/sbin/nologin' --home '/var/lib/testpkg' --group 'testpkg' >/dev/null",
+		"if ! id -nG 'testpkg' | tr ' ' '\\n' | grep -qxF 'testgrp'; then\n\t\tadduser 'testpkg' 'testgrp' >/dev/null",
+	} {
+		if !strings.Contains(postinst, fragment) {
+			t.Errorf("postinst should contain '%s', got:\n%s", fragment, postinst)
+		}
+	}
+	postrm := ss.Render("postrm")
+	if strings.Index(postrm, "deluser --system 'testpkg'") > strings.Index(postrm, "delgroup --system 'testgrp'") {
+		t.Errorf("Users should be removed before groups:\n%s", postrm)
+	}
+	if strings.Index(postrm, "delgroup --system 'testpkg'") < strings.Index(postrm, "deluser --system 'testpkg'") {
+		t.Errorf("The user's own group should be removed after the user:\n%s", postrm)
+	}
+
+	expected := "g testgrp -\nu testpkg - - /var/lib/testpkg /usr/sbin/nologin\nm testpkg testgrp\n"
+	if actual := string(debgen.SysusersConf(pkg)); actual != expected {
+		t.Errorf("Expected sysusers.d:\n%s\ngot:\n%s", expected, actual)
+	}
+	expected = "d /var/lib/testpkg 0755 testpkg testpkg -\n"
+	if actual := string(debgen.TmpfilesConf(pkg)); actual != expected {
+		t.Errorf("Expected tmpfiles.d:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestGenSystemAccounts(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	pkg.SystemUsers = []*deb.SystemUser{deb.NewSystemUser("testpkg")}
+	build := debgen.NewBuildParams()
+	build.IsSysusersConf = true
+	build.IsTmpfilesConf = true
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+	svc := debgen.NewSystemdService("testpkg", "/usr/bin/testpkg")
+	svc.User = "testpkg"
+	if err := dgen.AddSystemdService(svc); err != nil {
+		t.Fatalf("%v", err)
+	}
+	buf := new(bytes.Buffer)
+	if err := dgen.GenerateTo(buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	parsed, err := deb.DebParseMetadata(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if parsed.Depends != "adduser" {
+		t.Errorf("Expected Depends 'adduser', got '%s'", parsed.Depends)
+	}
+	postinst := new(bytes.Buffer)
+	if err = deb.DebExtractFileL2(bytes.NewReader(buf.Bytes()), deb.BinaryControlArchiveNameDefault, "postinst", postinst); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if strings.Index(postinst.String(), "adduser --system") > strings.Index(postinst.String(), "deb-systemd-invoke") {
+		t.Errorf("Users should be created before services start:\n%s", postinst.String())
+	}
+	contents, err := deb.DebGetContents(bytes.NewReader(buf.Bytes()), deb.BinaryDataArchiveNameDefault)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	joined := strings.Join(contents, "\n")
+	if !strings.Contains(joined, "./usr/lib/sysusers.d/testpkg.conf") || strings.Contains(joined, "tmpfiles.d/testpkg.conf") {
+		t.Errorf("Expected a sysusers.d file (and no tmpfiles.d file, without home directories), got:\n%s", joined)
+	}
+
+	pkg.SystemUsers = []*deb.SystemUser{deb.NewSystemUser("Not Valid")}
+	if err = dgen.GenerateTo(new(bytes.Buffer)); err == nil {
+		t.Errorf("Invalid users should fail")
+	}
+}
+
+func TestGenSystemAccountsWithControlResource(t *testing.T) {
+	resourcesDir, err := ioutil.TempDir("", "debgen-resources")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(resourcesDir)
+	if err = os.MkdirAll(filepath.Join(resourcesDir, "debian"), 0755); err != nil {
+		t.Fatalf("%v", err)
+	}
+	control := "Package: testpkg\nVersion: 0.0.2\nArchitecture: amd64\nMaintainer: me <a@me.org>\nDepends: libc6\nDescription: Dummy package\n for doing nothing\n"
+	if err = ioutil.WriteFile(filepath.Join(resourcesDir, "debian", "control"), []byte(control), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	pkg.SystemUsers = []*deb.SystemUser{deb.NewSystemUser("testpkg")}
+	build := debgen.NewBuildParams()
+	build.ResourcesDir = resourcesDir
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+	buf := new(bytes.Buffer)
+	if err = dgen.GenerateTo(buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	parsed, err := deb.DebParseMetadata(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if parsed.Depends != "libc6, adduser" || parsed.Description != "Dummy package\nfor doing nothing" {
+		t.Errorf("Expected the resource's control file with Depends on adduser, got Depends '%s' and Description '%s'", parsed.Depends, parsed.Description)
+	}
+	if parsed.InstalledSize == "" || parsed.InstalledSize != dgen.DebWriter.InstalledSizeField() {
+		t.Errorf("Expected Installed-Size %s, got '%s'", dgen.DebWriter.InstalledSizeField(), parsed.InstalledSize)
+	}
+}
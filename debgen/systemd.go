@@ -0,0 +1,191 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package debgen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SystemdUnitDirDefault is the directory into which systemd units are installed
+const SystemdUnitDirDefault = "/lib/systemd/system"
+
+// SystemdService describes a systemd unit installed by a binary package, and how the maintainer scripts manage it.
+//
+// The unit file is taken from a file in BuildParams.ResourcesDir/debian, or a template in BuildParams.TemplateDir/debian (named after the unit).
+// Otherwise a service unit is generated from the ExecStart, User, etc. fields (see TemplateSystemdService).
+type SystemdService struct {
+	Name    string // Unit name, e.g. 'foo.service'
+	UnitDir string // Installation directory. Default SystemdUnitDirDefault
+
+	Description string
+	Type        string // e.g. 'simple' or 'notify'. Optional
+	ExecStart   string // Command line, e.g. '/usr/bin/foo -bar'. Required for generated units
+	User        string
+	Group       string
+	Restart     string   // e.g. 'on-failure'
+	Environment []string // e.g. 'FOO=bar'
+	After       string   // Default 'network.target'
+	WantedBy    string   // Default 'multi-user.target'
+
+	IsEnable              bool // Whether to enable the unit on installation. Default true
+	IsStart               bool // Whether to start the unit on installation. Default true
+	IsRestartAfterUpgrade bool // Whether to restart the unit after an upgrade, rather than stopping it before. Default true
+}
+
+// NewSystemdService is a factory for SystemdService. '.service' is appended to names without a unit type.
+func NewSystemdService(name, execStart string) *SystemdService {
+	if !strings.Contains(name, ".") {
+		name += ".service"
+	}
+	return &SystemdService{
+		Name:                  name,
+		UnitDir:               SystemdUnitDirDefault,
+		Description:           strings.TrimSuffix(name, ".service"),
+		ExecStart:             execStart,
+		After:                 "network.target",
+		WantedBy:              "multi-user.target",
+		IsEnable:              true,
+		IsStart:               true,
+		IsRestartAfterUpgrade: true,
+	}
+}
+
+// AddSystemdService declares a systemd unit, installed into the data archive and managed by the maintainer scripts:
+// enabled, started (or restarted on upgrade), stopped on removal and cleaned up on purge, as dh_installsystemd does (see SystemdSnippets).
+//
+// The unit file is checked now, and generated along with the data archive.
+func (dgen *DebGenerator) AddSystemdService(svc *SystemdService) error {
+	_, err := dgen.systemdUnitData(svc)
+	if err != nil {
+		return err
+	}
+	dgen.SystemdServices = append(dgen.SystemdServices, svc)
+	return nil
+}
+
+// systemdUnitFiles generates the SystemdServices' unit files, by destination path
+func (dgen *DebGenerator) systemdUnitFiles() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for _, svc := range dgen.SystemdServices {
+		unitData, err := dgen.systemdUnitData(svc)
+		if err != nil {
+			return nil, err
+		}
+		unitDir := svc.UnitDir
+		if unitDir == "" {
+			unitDir = SystemdUnitDirDefault
+		}
+		files[path.Join(unitDir, svc.Name)] = unitData
+	}
+	return files, nil
+}
+
+func (dgen *DebGenerator) systemdUnitData(svc *SystemdService) ([]byte, error) {
+	if svc.Name == "" || strings.ContainsAny(svc.Name, "/ \t\n'") {
+		return nil, fmt.Errorf("Invalid systemd unit name '%s'", svc.Name)
+	}
+	resourcePath := filepath.Join(dgen.BuildParams.ResourcesDir, DebianDir, svc.Name)
+	_, err := os.Stat(resourcePath)
+	if err == nil {
+		return ioutil.ReadFile(resourcePath)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	templateVars := &TemplateData{Package: dgen.DebWriter.Package, Deb: dgen.DebWriter, Service: svc}
+	templatePath := filepath.Join(dgen.BuildParams.TemplateDir, DebianDir, svc.Name+TplExtension)
+	_, err = os.Stat(templatePath)
+	if err == nil {
+		return TemplateFile(templatePath, templateVars)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if !strings.HasSuffix(svc.Name, ".service") {
+		return nil, fmt.Errorf("No unit file found for %s", svc.Name)
+	}
+	if svc.ExecStart == "" {
+		return nil, fmt.Errorf("No unit file found for %s, and no ExecStart to generate one", svc.Name)
+	}
+	return TemplateString(TemplateSystemdService, templateVars)
+}
+
+// SystemdSnippets returns the maintainer script snippets for managing a systemd unit, mirroring dh_installsystemd.
+func SystemdSnippets(svc *SystemdService) []*ScriptSnippet {
+	provider := "debgo systemd"
+	unit := "'" + svc.Name + "'"
+	configureActions := []string{"configure", "abort-upgrade", "abort-deconfigure", "abort-remove"}
+	snippets := []*ScriptSnippet{}
+	if svc.IsEnable {
+		snippets = append(snippets, &ScriptSnippet{Script: "postinst", Actions: configureActions, Provider: provider, Code: `# This will only remove masks created by d-s-h on package removal.
+deb-systemd-helper unmask ` + unit + ` >/dev/null || true
+
+# was-enabled defaults to true, so new installations run enable.
+if deb-systemd-helper --quiet was-enabled ` + unit + `; then
+	# Enables the unit on first installation, creates new
+	# symlinks on upgrades if the unit file has changed.
+	deb-systemd-helper enable ` + unit + ` >/dev/null || true
+else
+	# Update the statefile to add new symlinks (if any), which need to be
+	# cleaned up on purge. Also remove old symlinks.
+	deb-systemd-helper update-state ` + unit + ` >/dev/null || true
+fi`})
+	}
+	if svc.IsStart {
+		start := `if [ -d /run/systemd/system ]; then
+	systemctl --system daemon-reload >/dev/null || true
+	deb-systemd-invoke start ` + unit + ` >/dev/null || true
+fi`
+		if svc.IsRestartAfterUpgrade {
+			start = `if [ -d /run/systemd/system ]; then
+	systemctl --system daemon-reload >/dev/null || true
+	if [ -n "$2" ]; then
+		_dh_action=restart
+	else
+		_dh_action=start
+	fi
+	deb-systemd-invoke $_dh_action ` + unit + ` >/dev/null || true
+fi`
+		}
+		snippets = append(snippets, &ScriptSnippet{Script: "postinst", Actions: configureActions, Provider: provider, Code: start})
+		stop := &ScriptSnippet{Script: "prerm", Provider: provider, Code: `if [ -d /run/systemd/system ]; then
+	deb-systemd-invoke stop ` + unit + ` >/dev/null || true
+fi`}
+		if svc.IsRestartAfterUpgrade {
+			// the postinst restarts the unit instead
+			stop.Actions = []string{"remove"}
+		}
+		snippets = append(snippets, stop)
+	}
+	// postrm snippets are reversed, so the reload comes first
+	snippets = append(snippets,
+		&ScriptSnippet{Script: "postrm", Actions: []string{"purge"}, Provider: provider, Code: `if [ -x "/usr/bin/deb-systemd-helper" ]; then
+	deb-systemd-helper purge ` + unit + ` >/dev/null || true
+	deb-systemd-helper unmask ` + unit + ` >/dev/null || true
+fi`},
+		&ScriptSnippet{Script: "postrm", Actions: []string{"remove"}, Provider: provider, Code: `if [ -x "/usr/bin/deb-systemd-helper" ]; then
+	deb-systemd-helper mask ` + unit + ` >/dev/null || true
+fi`},
+		&ScriptSnippet{Script: "postrm", Provider: provider, Code: `if [ -d /run/systemd/system ]; then
+	systemctl --system daemon-reload >/dev/null || true
+fi`},
+	)
+	return snippets
+}
@@ -0,0 +1,106 @@
+package debgen_test
+
+import (
+	"bytes"
+	"github.com/laher/debgo-v0.2/deb"
+	"github.com/laher/debgo-v0.2/debgen"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAddSystemdService(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "debgen-systemd")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	build := debgen.NewBuildParams()
+	build.TmpDir = tmpDir
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), build)
+	svc := debgen.NewSystemdService("testpkg", "/usr/bin/testpkg -serve")
+	svc.User = "testpkg"
+	svc.Restart = "on-failure"
+	if err = dgen.AddSystemdService(svc); err != nil {
+		t.Fatalf("%v", err)
+	}
+	buf := new(bytes.Buffer)
+	if err = dgen.GenerateTo(buf); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if tmpFiles, _ := ioutil.ReadDir(tmpDir); len(tmpFiles) > 0 {
+		t.Errorf("Unit files should be generated in memory, found %d temp file(s)", len(tmpFiles))
+	}
+	if len(dgen.Snippets.ForScript("postinst")) > 0 {
+		t.Errorf("Snippets should be generated along with the maintainer scripts")
+	}
+	unit := new(bytes.Buffer)
+	err = deb.DebExtractFileL2(bytes.NewReader(buf.Bytes()), deb.BinaryDataArchiveNameDefault, "lib/systemd/system/testpkg.service", unit)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := `[Unit]
+Description=testpkg
+After=network.target
+
+[Service]
+ExecStart=/usr/bin/testpkg -serve
+User=testpkg
+Restart=on-failure
+
+[Install]
+WantedBy=multi-user.target
+`
+	if unit.String() != expected {
+		t.Errorf("Expected unit:\n%s\ngot:\n%s", expected, unit.String())
+	}
+	scripts := map[string][]string{
+		"postinst": {"deb-systemd-helper enable 'testpkg.service'", "_dh_action=restart", "deb-systemd-invoke $_dh_action 'testpkg.service'"},
+		"prerm":    {`if [ "$1" = "remove" ]; then`, "deb-systemd-invoke stop 'testpkg.service'"},
+		"postrm":   {"deb-systemd-helper mask 'testpkg.service'", "deb-systemd-helper purge 'testpkg.service'"},
+	}
+	for name, fragments := range scripts {
+		script := new(bytes.Buffer)
+		err = deb.DebExtractFileL2(bytes.NewReader(buf.Bytes()), deb.BinaryControlArchiveNameDefault, name, script)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, fragment := range fragments {
+			if !strings.Contains(script.String(), fragment) {
+				t.Errorf("%s should contain '%s', got:\n%s", name, fragment, script.String())
+			}
+		}
+	}
+}
+
+func TestSystemdSnippetsWithoutRestart(t *testing.T) {
+	svc := debgen.NewSystemdService("foo.service", "/usr/bin/foo")
+	svc.IsEnable = false
+	svc.IsRestartAfterUpgrade = false
+	ss := debgen.NewScriptSnippets()
+	for _, snippet := range debgen.SystemdSnippets(svc) {
+		if err := ss.Add(snippet); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	postinst := ss.Render("postinst")
+	if strings.Contains(postinst, "enable") || !strings.Contains(postinst, "deb-systemd-invoke start 'foo.service'") {
+		t.Errorf("Unexpected postinst:\n%s", postinst)
+	}
+	if prerm := ss.Render("prerm"); strings.Contains(prerm, `"$1"`) {
+		t.Errorf("prerm should stop the unit for all actions:\n%s", prerm)
+	}
+	if postrm := ss.Render("postrm"); !strings.HasPrefix(postrm, "# Automatically added by debgo systemd\nif [ -d /run/systemd/system ]; then\n\tsystemctl --system daemon-reload") {
+		t.Errorf("postrm should reload first:\n%s", postrm)
+	}
+}
+
+func TestAddSystemdServiceWithoutUnit(t *testing.T) {
+	pkg := deb.NewPackage("testpkg", "0.0.2", "me <a@me.org>", "Dummy package for doing nothing\n")
+	dgen := debgen.NewDebGenerator(deb.NewDebWriter(pkg, deb.ArchAmd64), debgen.NewBuildParams())
+	if err := dgen.AddSystemdService(debgen.NewSystemdService("testpkg.socket", "")); err == nil {
+		t.Errorf("Units without a unit file or ExecStart should fail")
+	}
+}
@@ -22,13 +22,52 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
 
-// TarHeader is a factory for a tar header. Fixes slashes, populates ModTime
+// TarWriter writes tar entries. It is implemented by *tar.Writer
+type TarWriter interface {
+	WriteHeader(hdr *tar.Header) error
+	Write(b []byte) (int, error)
+}
+
+type reproducibleTarWriter struct {
+	TarWriter
+	modTime time.Time
+}
+
+// NewReproducibleTarWriter wraps a TarWriter, normalising each entry's header for reproducible builds.
+// Modification times are clamped to modTime, and the group & other write bits (which depend on the umask) are cleared.
+// Other permissions and ownership are kept as given. Symlinks' permissions, which vary by platform, are set to 0777.
+func NewReproducibleTarWriter(tw TarWriter, modTime time.Time) TarWriter {
+	return &reproducibleTarWriter{tw, modTime}
+}
+
+func (rtw *reproducibleTarWriter) WriteHeader(hdr *tar.Header) error {
+	normalised := *hdr
+	if normalised.ModTime.After(rtw.modTime) || normalised.ModTime.IsZero() {
+		normalised.ModTime = rtw.modTime
+	}
+	normalised.ModTime = normalised.ModTime.Truncate(time.Second)
+	normalised.AccessTime = time.Time{}
+	normalised.ChangeTime = time.Time{}
+	normalised.PAXRecords = nil
+	if normalised.Typeflag == tar.TypeSymlink {
+		normalised.Mode = 0777
+	} else {
+		normalised.Mode &^= 0022
+	}
+	return rtw.TarWriter.WriteHeader(&normalised)
+}
+
+// TarHeader is a factory for a regular file's tar header. Fixes slashes, populates ModTime, and sets root:root ownership
 func TarHeader(path string, datalen int64, mode int64) *tar.Header {
 	h := new(tar.Header)
+	h.Typeflag = tar.TypeReg
+	h.Uname = TarOwnerDefault
+	h.Gname = TarOwnerDefault
 	//slash-only paths
 	h.Name = strings.Replace(path, "\\", "/", -1)
 	if strings.HasPrefix(h.Name, "/") {
@@ -41,14 +80,13 @@ func TarHeader(path string, datalen int64, mode int64) *tar.Header {
 }
 
 // TarAddFile adds a file from the file system
-// This is just a helper function
-// TODO: directories
-func TarAddFile(tw *tar.Writer, sourceFile, destName string) error {
+// This is just a helper function. For directories, see TarAddFileOrDir. For data archives, see TarEntries
+func TarAddFile(tw TarWriter, sourceFile, destName string) error {
 	fi, err := os.Open(sourceFile)
-	defer fi.Close()
 	if err != nil {
 		return err
 	}
+	defer fi.Close()
 	finf, err := fi.Stat()
 	if err != nil {
 		return err
@@ -69,7 +107,9 @@ func TarAddFile(tw *tar.Writer, sourceFile, destName string) error {
 	return nil
 }
 
-func TarAddFileOrDir(tw *tar.Writer, sourceFile, destName string) error {
+// TarAddFileOrDir adds a file, or the files inside a directory tree, from the file system.
+// Files inside a directory are added relative to destName.
+func TarAddFileOrDir(tw TarWriter, sourceFile, destName string) error {
 	finf, err := os.Stat(sourceFile)
 	if err != nil {
 		return err
@@ -80,7 +120,7 @@ func TarAddFileOrDir(tw *tar.Writer, sourceFile, destName string) error {
 			if info != nil && !info.IsDir() {
 				rel, err := filepath.Rel(sourceFile, path)
 				if err == nil {
-					return TarAddFile(tw, rel, path)
+					return TarAddFile(tw, path, filepath.Join(destName, rel))
 				}
 				return err
 			}
@@ -95,20 +135,24 @@ func TarAddFileOrDir(tw *tar.Writer, sourceFile, destName string) error {
 
 // TarAddFiles adds resources from file system.
 // The key should be the destination filename. Value is the local filesystem path
-func TarAddFiles(tw *tar.Writer, resources map[string]string) error {
-	if resources != nil {
-		for name, localPath := range resources {
-			err := TarAddFile(tw, localPath, name)
-			if err != nil {
-				return err
-			}
+// Files are added in order of their destination filenames.
+func TarAddFiles(tw TarWriter, resources map[string]string) error {
+	names := []string{}
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err := TarAddFile(tw, resources[name], name)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
 // TarAddBytes adds a file by bytes with a given path
-func TarAddBytes(tw *tar.Writer, bytes []byte, destName string, mode int64) error {
+func TarAddBytes(tw TarWriter, bytes []byte, destName string, mode int64) error {
 	err := tw.WriteHeader(TarHeader(destName, int64(len(bytes)), mode))
 	if err != nil {
 		return err
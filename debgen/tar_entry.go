@@ -0,0 +1,297 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package debgen
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"github.com/laher/debgo-v0.2/deb"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Owner of data archive entries
+const (
+	TarOwnerDefault = "root"
+	TarUidDefault   = 0
+)
+
+// ustarNameMax is the longest name which fits in a plain tar header
+const ustarNameMax = 100
+
+// TarEntry describes an entry in a data archive.
+type TarEntry struct {
+	Name       string    // Path inside the archive, e.g. '/usr/bin/foo'
+	Type       byte      // tar.TypeReg, tar.TypeDir, tar.TypeSymlink or tar.TypeLink
+	Mode       int64     // Permission bits, including the setuid, setgid and sticky bits (04000, 02000 and 01000)
+	Uid        int       // Owner's user id
+	Gid        int       // Owner's group id
+	Uname      string    // Owner's user name
+	Gname      string    // Owner's group name
+	ModTime    time.Time // Modification time
+	Linkname   string    // Target of a symlink, or the path (inside the archive) of a hardlink's file
+	Size       int64     // Size of a regular file
+	SourceFile string    // Local path of a regular file's contents
+	Data       []byte    // Contents of a generated regular file, used instead of SourceFile
+}
+
+// NewTarEntry is a factory for TarEntry, owned by root:root.
+func NewTarEntry(name string, typ byte, mode int64, modTime time.Time) *TarEntry {
+	return &TarEntry{Name: TarEntryName(name), Type: typ, Mode: mode, ModTime: modTime,
+		Uid: TarUidDefault, Gid: TarUidDefault, Uname: TarOwnerDefault, Gname: TarOwnerDefault}
+}
+
+// TarEntryName normalises a path inside the archive to the form used by dpkg-deb, e.g. '/usr/bin/foo' becomes './usr/bin/foo'.
+func TarEntryName(name string) string {
+	name = path.Clean("/" + strings.Replace(name, "\\", "/", -1))
+	if name == "/" {
+		return "./"
+	}
+	return "." + name
+}
+
+// Header creates the tar header for this entry.
+// GNU headers are used when names are too long for a plain header, as dpkg-deb does.
+func (e *TarEntry) Header() *tar.Header {
+	hdr := &tar.Header{
+		Name:     e.Name,
+		Typeflag: e.Type,
+		Mode:     e.Mode,
+		Uid:      e.Uid,
+		Gid:      e.Gid,
+		Uname:    e.Uname,
+		Gname:    e.Gname,
+		ModTime:  e.ModTime,
+		Linkname: e.Linkname,
+	}
+	if e.Type == tar.TypeDir && !strings.HasSuffix(hdr.Name, "/") {
+		hdr.Name += "/"
+	}
+	if e.Type == tar.TypeReg {
+		hdr.Size = e.Size
+	}
+	if len(hdr.Name) > ustarNameMax || len(hdr.Linkname) > ustarNameMax {
+		hdr.Format = tar.FormatGNU
+	}
+	return hdr
+}
+
+// TarEntries builds data archive entries for mapped files.
+// The key should be the destination path, and the value the local filesystem path.
+// Local directories are added recursively. Symlinks are preserved, and files which are hardlinked together are stored as hardlinks.
+// Parent directories are added as necessary. Entries are sorted by name, and owned by root:root.
+func TarEntries(mappedFiles map[string]string) ([]*TarEntry, error) {
+	return TarEntriesWithContents(mappedFiles, nil)
+}
+
+// TarEntriesWithContents builds data archive entries for mapped files (see TarEntries), plus generated files.
+// The key of contents should be the destination path, and the value the file's contents. Generated files have mode 0644.
+func TarEntriesWithContents(mappedFiles map[string]string, contents map[string][]byte) ([]*TarEntry, error) {
+	entries := map[string]*TarEntry{}
+	for destName, localPath := range mappedFiles {
+		err := addTarEntries(entries, destName, localPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for destName, data := range contents {
+		name := TarEntryName(destName)
+		if _, exists := entries[name]; exists {
+			return nil, fmt.Errorf("Duplicate entry '%s' in data archive", name)
+		}
+		entry := NewTarEntry(name, tar.TypeReg, 0644, time.Now())
+		entry.Size = int64(len(data))
+		entry.Data = data
+		entries[name] = entry
+	}
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
+	}
+	// parent directories
+	for _, name := range names {
+		for dir := path.Dir(strings.TrimPrefix(name, ".")); dir != "/"; dir = path.Dir(dir) {
+			existing, exists := entries[TarEntryName(dir)]
+			if !exists {
+				entries[TarEntryName(dir)] = NewTarEntry(dir, tar.TypeDir, 0755, time.Now())
+			} else if existing.Type != tar.TypeDir {
+				return nil, fmt.Errorf("Entry '%s' in data archive is inside '%s', which is not a directory", name, existing.Name)
+			}
+		}
+	}
+	if _, exists := entries["./"]; !exists {
+		entries["./"] = NewTarEntry("/", tar.TypeDir, 0755, time.Now())
+	}
+	ret := []*TarEntry{}
+	for _, entry := range entries {
+		ret = append(ret, entry)
+	}
+	sort.Sort(tarEntriesByName(ret))
+	linkHardlinks(ret)
+	return ret, nil
+}
+
+// addTarEntries adds an entry for the local file, or entries for the local directory tree
+// A mapped symlink is followed, but symlinks inside a mapped directory are preserved.
+func addTarEntries(entries map[string]*TarEntry, destName, localPath string) error {
+	resolved, err := filepath.EvalSymlinks(localPath)
+	if err != nil {
+		return err
+	}
+	localPath = resolved
+	return filepath.Walk(localPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		name := TarEntryName(path.Join(filepath.ToSlash(destName), filepath.ToSlash(rel)))
+		if existing, exists := entries[name]; exists && (existing.Type != tar.TypeDir || !info.IsDir()) {
+			return fmt.Errorf("Duplicate entry '%s' in data archive", name)
+		}
+		entry := NewTarEntry(name, tar.TypeReg, tarMode(info.Mode()), info.ModTime())
+		switch {
+		case info.IsDir():
+			entry.Type = tar.TypeDir
+		case info.Mode()&os.ModeSymlink != 0:
+			entry.Type = tar.TypeSymlink
+			entry.Mode = 0777
+			entry.Linkname, err = os.Readlink(p)
+			if err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			entry.Size = info.Size()
+			entry.SourceFile = p
+		default:
+			return fmt.Errorf("Unsupported file type for '%s': %v", p, info.Mode())
+		}
+		entries[name] = entry
+		return nil
+	})
+}
+
+// tarMode converts a file mode to tar's mode bits, as c_ISUID, c_ISGID and c_ISVTX for the special bits
+func tarMode(mode os.FileMode) int64 {
+	ret := int64(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		ret |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		ret |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		ret |= 01000
+	}
+	return ret
+}
+
+// linkHardlinks converts regular files into hardlinks, where they're the same file as an earlier entry
+func linkHardlinks(entries []*TarEntry) {
+	type linked struct {
+		info os.FileInfo
+		name string
+	}
+	seen := []linked{}
+	for _, entry := range entries {
+		if entry.Type != tar.TypeReg || entry.Data != nil {
+			continue
+		}
+		info, err := os.Stat(entry.SourceFile)
+		if err != nil {
+			continue
+		}
+		isLink := false
+		for _, s := range seen {
+			if os.SameFile(s.info, info) {
+				entry.Type = tar.TypeLink
+				entry.Linkname = s.name
+				entry.Size = 0
+				isLink = true
+				break
+			}
+		}
+		if !isLink {
+			seen = append(seen, linked{info, entry.Name})
+		}
+	}
+}
+
+type tarEntriesByName []*TarEntry
+
+func (s tarEntriesByName) Len() int           { return len(s) }
+func (s tarEntriesByName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+func (s tarEntriesByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// WriteTarEntries writes entries into the tar archive.
+// If md5sums is not nil, the MD5 digest of each regular file (and hardlink) is recorded into it, keyed by its md5sums path (see deb.Md5SumsPath).
+func WriteTarEntries(tw TarWriter, entries []*TarEntry, md5sums map[string]string) error {
+	for _, entry := range entries {
+		err := tw.WriteHeader(entry.Header())
+		if err != nil {
+			return err
+		}
+		switch entry.Type {
+		case tar.TypeReg:
+			err = writeTarEntryContents(tw, entry, md5sums)
+			if err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			if md5sums != nil {
+				md5sums[deb.Md5SumsPath(entry.Name)] = md5sums[deb.Md5SumsPath(entry.Linkname)]
+			}
+		}
+	}
+	return nil
+}
+
+func writeTarEntryContents(tw TarWriter, entry *TarEntry, md5sums map[string]string) error {
+	var rdr io.Reader
+	if entry.Data != nil {
+		rdr = bytes.NewReader(entry.Data)
+	} else {
+		fi, err := os.Open(entry.SourceFile)
+		if err != nil {
+			return err
+		}
+		defer fi.Close()
+		rdr = fi
+	}
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(tw, h), rdr)
+	if err != nil {
+		return err
+	}
+	if n != entry.Size {
+		return fmt.Errorf("File '%s' changed size while being archived", entry.SourceFile)
+	}
+	if md5sums != nil {
+		md5sums[deb.Md5SumsPath(entry.Name)] = hex.EncodeToString(h.Sum(nil))
+	}
+	return nil
+}
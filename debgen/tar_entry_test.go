@@ -0,0 +1,206 @@
+package debgen_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"github.com/laher/debgo-v0.2/debgen"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTarEntries(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "debgen-entries")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(srcDir)
+	longName := strings.Repeat("x", 120)
+	for _, f := range []string{"bin/a", "share/doc/README", "share/" + longName} {
+		f = filepath.Join(srcDir, f)
+		if err = os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err = ioutil.WriteFile(f, []byte("echo 1\n"), 0755); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if err = os.Symlink("a", filepath.Join(srcDir, "bin", "b")); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err = os.Link(filepath.Join(srcDir, "bin", "a"), filepath.Join(srcDir, "bin", "c")); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	entries, err := debgen.TarEntries(map[string]string{"/usr": srcDir})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Header().Name)
+		if entry.Uid != 0 || entry.Gid != 0 || entry.Uname != "root" || entry.Gname != "root" {
+			t.Errorf("%s should be owned by root:root", entry.Name)
+		}
+	}
+	expected := []string{"./", "./usr/", "./usr/bin/", "./usr/bin/a", "./usr/bin/b", "./usr/bin/c",
+		"./usr/share/", "./usr/share/doc/", "./usr/share/doc/README", "./usr/share/" + longName}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected entries %v, got %v", expected, names)
+	}
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	md5sums := map[string]string{}
+	if err = debgen.WriteTarEntries(tw, entries, md5sums); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err = tw.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	tr := tar.NewReader(buf)
+	headers := map[string]*tar.Header{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		headers[hdr.Name] = hdr
+	}
+	if hdr := headers["./usr/bin/b"]; hdr == nil || hdr.Typeflag != tar.TypeSymlink || hdr.Linkname != "a" {
+		t.Errorf("Expected a symlink to 'a', got %+v", hdr)
+	}
+	if hdr := headers["./usr/bin/c"]; hdr == nil || hdr.Typeflag != tar.TypeLink || hdr.Linkname != "./usr/bin/a" {
+		t.Errorf("Expected a hardlink to './usr/bin/a', got %+v", hdr)
+	}
+	if hdr := headers["./usr/share/"+longName]; hdr == nil || hdr.Size != 7 {
+		t.Errorf("Long name not preserved: %+v", hdr)
+	}
+	if md5sums["usr/bin/c"] == "" || md5sums["usr/bin/c"] != md5sums["usr/bin/a"] {
+		t.Errorf("Hardlinks should have md5sums: %v", md5sums)
+	}
+	if _, exists := md5sums["usr/bin/b"]; exists {
+		t.Errorf("Symlinks should not have md5sums")
+	}
+}
+
+func TestTarEntriesModes(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "debgen-modes")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(srcDir)
+	if err = os.MkdirAll(filepath.Join(srcDir, "bin"), 0755); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(srcDir, "bin", "a"), []byte("a"), 0755); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err = os.Chmod(filepath.Join(srcDir, "bin", "a"), 0755|os.ModeSetuid|os.ModeSetgid); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err = os.Mkdir(filepath.Join(srcDir, "tmp"), 0777); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err = os.Chmod(filepath.Join(srcDir, "tmp"), 0777|os.ModeSticky); err != nil {
+		t.Fatalf("%v", err)
+	}
+	entries, err := debgen.TarEntries(map[string]string{"/": srcDir})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	modes := map[string]int64{}
+	for _, entry := range entries {
+		modes[entry.Name] = entry.Mode
+	}
+	if modes["./bin/a"] != 06755 {
+		t.Errorf("Expected setuid and setgid bits, got %o", modes["./bin/a"])
+	}
+	if modes["./tmp"] != 01777 {
+		t.Errorf("Expected the sticky bit, got %o", modes["./tmp"])
+	}
+
+	// a file can't replace a directory, or contain other entries
+	clashes := []map[string]string{
+		{"/": srcDir, "/bin": filepath.Join(srcDir, "bin", "a")},
+		{"/usr/bin/a": filepath.Join(srcDir, "bin", "a"), "/usr/bin/a/b": filepath.Join(srcDir, "bin", "a")},
+	}
+	for _, mappedFiles := range clashes {
+		if _, err = debgen.TarEntries(mappedFiles); err == nil {
+			t.Errorf("Expected an error for %v", mappedFiles)
+		}
+	}
+}
+
+func TestTarAddFileOrDir(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "debgen-addfileordir")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(srcDir)
+	if err = ioutil.WriteFile(filepath.Join(srcDir, "a"), []byte("a"), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	if err = debgen.TarAddFileOrDir(tw, srcDir, "share/testpkg"); err != nil {
+		t.Fatalf("%v", err)
+	}
+	tw.Close()
+	hdr, err := tar.NewReader(buf).Next()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if hdr.Name != "share/testpkg/a" {
+		t.Errorf("Unexpected name %s", hdr.Name)
+	}
+}
+
+func TestReproducibleTarWriter(t *testing.T) {
+	epoch := time.Unix(1500000000, 0).UTC()
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	rtw := debgen.NewReproducibleTarWriter(tw, epoch)
+	hdrs := []*tar.Header{
+		{Name: "./usr/", Typeflag: tar.TypeDir, Mode: 0700, ModTime: epoch.Add(time.Hour), Uid: 1000, Uname: "me"},
+		{Name: "./usr/a", Typeflag: tar.TypeReg, Mode: 0600, ModTime: epoch.Add(-time.Hour), Gid: 1000, Gname: "me"},
+		{Name: "./usr/b", Typeflag: tar.TypeReg, Mode: 04700, ModTime: epoch.Add(time.Hour + time.Millisecond)},
+		{Name: "./usr/c", Typeflag: tar.TypeSymlink, Linkname: "a", Mode: 0755},
+		{Name: "./usr/d", Typeflag: tar.TypeReg, Mode: 0775, ModTime: epoch},
+	}
+	for _, hdr := range hdrs {
+		if err := rtw.WriteHeader(hdr); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := []struct {
+		mode    int64
+		modTime time.Time
+		uid     int
+		uname   string
+	}{{0700, epoch, 1000, "me"}, {0600, epoch.Add(-time.Hour), 0, ""}, {04700, epoch, 0, ""}, {0777, epoch, 0, ""}, {0755, epoch, 0, ""}}
+	tr := tar.NewReader(buf)
+	for _, e := range expected {
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if hdr.Mode != e.mode || !hdr.ModTime.Equal(e.modTime) {
+			t.Errorf("%s: expected mode %o and mtime %v, got %o and %v", hdr.Name, e.mode, e.modTime, hdr.Mode, hdr.ModTime)
+		}
+		if hdr.Uid != e.uid || hdr.Uname != e.uname {
+			t.Errorf("%s: expected owner %d (%s), got %d (%s)", hdr.Name, e.uid, e.uname, hdr.Uid, hdr.Uname)
+		}
+	}
+}
@@ -27,20 +27,27 @@ import (
 
 // initialize "template data" object
 func NewTemplateData(pkg *deb.Package) *TemplateData {
+	return NewTemplateDataAt(pkg, time.Now())
+}
+
+// NewTemplateDataAt is a factory for TemplateData, with the changelog EntryDate set to t (e.g. BuildParams.Timestamp())
+func NewTemplateDataAt(pkg *deb.Package, t time.Time) *TemplateData {
 	//Entry date format day-of-week, dd month yyyy hh:mm:ss +zzzz
-	t := time.Now()
 	entryDate := t.Format(ChangelogDateLayout)
 	templateVars := TemplateData{Package: pkg, EntryDate: entryDate, Checksums: nil}
 	return &templateVars
 }
 
-//Data for templates
+// Data for templates
 type TemplateData struct {
-	Package   *deb.Package
-	Deb       *deb.DebWriter
-	EntryDate      string
-	ChangelogEntry string
-	Checksums *deb.Checksums
+	Package          *deb.Package
+	Deb              *deb.DebWriter
+	EntryDate        string
+	ChangelogEntry   string
+	Checksums        *deb.Checksums
+	InstalledSize    string                 // Installed-Size for binary packages. See deb.DebWriter.InstalledSizeField
+	DebconfTemplates []*deb.DebconfTemplate // Debconf templates for binary packages
+	Service          *SystemdService        // The service, for systemd unit templates
 }
 
 func TemplateFileOrString(templateFile string, templateDefault string, vars interface{}) ([]byte, error) {
@@ -52,7 +59,7 @@ func TemplateFileOrString(templateFile string, templateDefault string, vars inte
 	} else if err != nil {
 		return nil, err
 	} else {
-		return TemplateFile(tplText, vars)
+		return TemplateFile(templateFile, vars)
 	}
 }
 
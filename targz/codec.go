@@ -0,0 +1,229 @@
+/*
+   Copyright 2013 Am Laher
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package targz
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// DefaultCompression selects the codec's default compression level.
+const DefaultCompression = -1
+
+// Codec names
+const (
+	CodecGzip  = "gzip"
+	CodecXz    = "xz"
+	CodecZstd  = "zstd"
+	CodecBzip2 = "bzip2"
+	CodecNone  = "none"
+)
+
+// Codec compresses and decompresses the stream underlying a tar archive.
+type Codec interface {
+	Name() string      // Name, e.g. 'gzip'
+	Extension() string // Filename extension, e.g. '.gz'. Empty for uncompressed archives
+	Magic() []byte     // Leading bytes of a compressed stream. Empty for uncompressed archives
+
+	// NewReader wraps r with a decompressor.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+	// NewWriter wraps w with a compressor. level is codec-specific, or DefaultCompression.
+	NewWriter(w io.Writer, level int) (io.WriteCloser, error)
+}
+
+var codecs = []Codec{
+	gzipCodec{},
+	xzCodec{},
+	zstdCodec{},
+	bzip2Codec{},
+	noneCodec{},
+}
+
+// RegisterCodec adds a codec, replacing any existing codec of the same name.
+func RegisterCodec(codec Codec) {
+	for i, existing := range codecs {
+		if existing.Name() == codec.Name() {
+			codecs[i] = codec
+			return
+		}
+	}
+	// keep 'none' last, so that it doesn't shadow other extensions
+	codecs = append([]Codec{codec}, codecs...)
+}
+
+// Codecs returns the registered codecs.
+func Codecs() []Codec {
+	return append([]Codec{}, codecs...)
+}
+
+// CodecByName finds a codec by name. An empty name is treated as gzip.
+func CodecByName(name string) (Codec, error) {
+	if name == "" {
+		name = CodecGzip
+	}
+	for _, codec := range codecs {
+		if codec.Name() == name {
+			return codec, nil
+		}
+	}
+	return nil, fmt.Errorf("Unsupported compression '%s'", name)
+}
+
+// CodecByFilename finds a codec by the archive's filename, e.g. 'data.tar.xz'.
+func CodecByFilename(filename string) (Codec, error) {
+	for _, codec := range codecs {
+		if strings.HasSuffix(filename, ".tar"+codec.Extension()) {
+			return codec, nil
+		}
+	}
+	return nil, fmt.Errorf("Unsupported archive type: %s", filename)
+}
+
+// TrimExtension removes the tar and compression extensions from an archive's filename, e.g. 'data.tar.xz' becomes 'data'.
+func TrimExtension(filename string) string {
+	codec, err := CodecByFilename(filename)
+	if err != nil {
+		return filename
+	}
+	return strings.TrimSuffix(filename, ".tar"+codec.Extension())
+}
+
+// DetectCodec identifies a compressed stream by its magic bytes. Streams without a known signature are assumed to be uncompressed.
+func DetectCodec(br *bufio.Reader) (Codec, error) {
+	for _, codec := range codecs {
+		magic := codec.Magic()
+		if len(magic) == 0 {
+			continue
+		}
+		b, err := br.Peek(len(magic))
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+		if bytes.Equal(b, magic) {
+			return codec, nil
+		}
+	}
+	return CodecByName(CodecNone)
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string      { return CodecGzip }
+func (gzipCodec) Extension() string { return ".gz" }
+func (gzipCodec) Magic() []byte     { return []byte{0x1f, 0x8b} }
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// NewWriter leaves the gzip header's name and mtime unset, so output is reproducible.
+func (gzipCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, level)
+}
+
+type xzCodec struct{}
+
+func (xzCodec) Name() string      { return CodecXz }
+func (xzCodec) Extension() string { return ".xz" }
+func (xzCodec) Magic() []byte     { return []byte{0xfd, '7', 'z', 'X', 'Z', 0x00} }
+
+func (xzCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	xzr, err := xz.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(xzr), nil
+}
+
+// xzDictCaps are the dictionary sizes of xz's presets (0-9).
+var xzDictCaps = []int{256 << 10, 1 << 20, 2 << 20, 4 << 20, 4 << 20, 8 << 20, 8 << 20, 16 << 20, 32 << 20, 64 << 20}
+
+// NewWriter accepts xz levels (0-9), which set the dictionary size of the corresponding xz preset.
+func (xzCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == DefaultCompression {
+		return xz.NewWriter(w)
+	}
+	if level < 0 || level >= len(xzDictCaps) {
+		return nil, fmt.Errorf("Invalid xz compression level %d", level)
+	}
+	return xz.WriterConfig{DictCap: xzDictCaps[level]}.NewWriter(w)
+}
+
+type zstdCodec struct{}
+
+func (zstdCodec) Name() string      { return CodecZstd }
+func (zstdCodec) Extension() string { return ".zst" }
+func (zstdCodec) Magic() []byte     { return []byte{0x28, 0xb5, 0x2f, 0xfd} }
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return zr.IOReadCloser(), nil
+}
+
+// NewWriter accepts zstd levels (1-22).
+func (zstdCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	if level == DefaultCompression {
+		return zstd.NewWriter(w)
+	}
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+}
+
+type bzip2Codec struct{}
+
+func (bzip2Codec) Name() string      { return CodecBzip2 }
+func (bzip2Codec) Extension() string { return ".bz2" }
+func (bzip2Codec) Magic() []byte     { return []byte("BZh") }
+
+func (bzip2Codec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(bzip2.NewReader(r)), nil
+}
+
+// NewWriter always fails. bzip2 archives can be read but not written (as with current versions of dpkg-deb).
+func (bzip2Codec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return nil, fmt.Errorf("Writing bzip2 archives is not supported")
+}
+
+type noneCodec struct{}
+
+func (noneCodec) Name() string      { return CodecNone }
+func (noneCodec) Extension() string { return "" }
+func (noneCodec) Magic() []byte     { return nil }
+
+func (noneCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(r), nil
+}
+
+func (noneCodec) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
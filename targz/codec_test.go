@@ -0,0 +1,104 @@
+package targz_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"github.com/laher/debgo-v0.2/targz"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	for _, name := range []string{targz.CodecGzip, targz.CodecXz, targz.CodecZstd, targz.CodecNone} {
+		codec, err := targz.CodecByName(name)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, level := range []int{targz.DefaultCompression, 1} {
+			buf := new(bytes.Buffer)
+			tgzw, err := targz.NewWriterWithCodec(buf, codec, level)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			body := []byte("hello, " + name)
+			if err = tgzw.WriteHeader(&tar.Header{Name: "hello.txt", Size: int64(len(body)), Mode: 0644}); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if _, err = tgzw.Write(body); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if err = tgzw.Close(); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+
+			tgzr, err := targz.NewReaderWithName(bytes.NewReader(buf.Bytes()), "data.tar"+codec.Extension())
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if tgzr.Codec.Name() != name {
+				t.Errorf("Expected %s to be detected, got %s", name, tgzr.Codec.Name())
+			}
+			hdr, err := tgzr.Next()
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			read, err := ioutil.ReadAll(tgzr)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if hdr.Name != "hello.txt" || !bytes.Equal(read, body) {
+				t.Errorf("%s: unexpected contents %s: %s", name, hdr.Name, read)
+			}
+			tgzr.Close()
+		}
+	}
+}
+
+func TestCodecByFilename(t *testing.T) {
+	names := map[string]string{
+		"data.tar.gz":  targz.CodecGzip,
+		"data.tar.xz":  targz.CodecXz,
+		"data.tar.zst": targz.CodecZstd,
+		"data.tar.bz2": targz.CodecBzip2,
+		"data.tar":     targz.CodecNone,
+	}
+	for filename, expected := range names {
+		codec, err := targz.CodecByFilename(filename)
+		if err != nil {
+			t.Errorf("%s: %v", filename, err)
+		} else if codec.Name() != expected {
+			t.Errorf("%s: expected %s, got %s", filename, expected, codec.Name())
+		}
+		if targz.TrimExtension(filename) != "data" {
+			t.Errorf("%s: unexpected trimmed name %s", filename, targz.TrimExtension(filename))
+		}
+	}
+	if _, err := targz.CodecByFilename("data.tar.lz4"); err == nil {
+		t.Errorf("Expected an error for an unknown extension")
+	}
+	// name says compressed, data isn't
+	if _, err := targz.NewReaderWithName(bytes.NewReader(make([]byte, 1024)), "data.tar.xz"); err == nil {
+		t.Errorf("Expected an error for a mis-named archive")
+	}
+	bz2, _ := targz.CodecByName(targz.CodecBzip2)
+	if _, err := targz.NewWriterWithCodec(new(bytes.Buffer), bz2, targz.DefaultCompression); err == nil {
+		t.Errorf("Expected an error writing bzip2")
+	}
+}
+
+func TestXzLevels(t *testing.T) {
+	codec, err := targz.CodecByName(targz.CodecXz)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, level := range []int{0, 9} {
+		if _, err = codec.NewWriter(new(bytes.Buffer), level); err != nil {
+			t.Errorf("Level %d: %v", level, err)
+		}
+	}
+	for _, level := range []int{-2, 10} {
+		if _, err = codec.NewWriter(new(bytes.Buffer), level); err == nil {
+			t.Errorf("Level %d should be rejected", level)
+		}
+	}
+}
@@ -18,44 +18,63 @@ package targz
 
 import (
 	"archive/tar"
-	"compress/gzip"
+	"bufio"
+	"fmt"
 	"io"
 )
 
-// A Reader provides sequential access to the contents of a tar.gz archive.
-// A tar.gz archive consists of a sequence of files.
+// A Reader provides sequential access to the contents of a compressed tar archive.
+// A tar archive consists of a sequence of files.
 // The Next method advances to the next file in the archive (including the first),
 // and then it can be treated as an io.Reader to access the file's data.
 type Reader struct {
 	*tar.Reader
 	WrappedReader io.Reader
-	GzipReader    *gzip.Reader
+	Codec         Codec         // Compression codec, detected from the stream
+	Decompressor  io.ReadCloser // Decompressing reader (wraps the WrappedReader)
 }
 
 // NewReader creates a new Reader reading from r.
+// The compression is detected using the stream's magic bytes.
 func NewReader(r io.Reader) (*Reader, error) {
-	tgzr := &Reader{WrappedReader: r}
-	var err error
-	tgzr.GzipReader, err = gzip.NewReader(tgzr.WrappedReader)
+	return NewReaderWithName(r, "")
+}
+
+// NewReaderWithName creates a new Reader reading from r.
+// The compression is detected using the stream's magic bytes, and checked against the filename's extension (if any).
+func NewReaderWithName(r io.Reader, filename string) (*Reader, error) {
+	br := bufio.NewReader(r)
+	codec, err := DetectCodec(br)
 	if err != nil {
 		return nil, err
 	}
-	tgzr.Reader = tar.NewReader(tgzr.GzipReader)
-	return tgzr, err
-}
-
-/*
-func (r *Reader) Next() (*tar.Header, error) {
-	return r.Tr.Next()
+	if filename != "" {
+		named, err := CodecByFilename(filename)
+		if err != nil {
+			return nil, err
+		}
+		// an unrecognised signature is only acceptable for an uncompressed archive
+		if codec.Name() == CodecNone && named.Name() != CodecNone {
+			return nil, fmt.Errorf("Archive %s is not %s-compressed", filename, named.Name())
+		}
+	}
+	return NewReaderWithCodec(br, codec)
 }
 
-func (r *Reader) Read(b []byte) (int, error) {
-	return r.Tr.Read(b)
+// NewReaderWithCodec creates a new Reader reading from r, using the given codec.
+func NewReaderWithCodec(r io.Reader, codec Codec) (*Reader, error) {
+	tgzr := &Reader{WrappedReader: r, Codec: codec}
+	var err error
+	tgzr.Decompressor, err = codec.NewReader(tgzr.WrappedReader)
+	if err != nil {
+		return nil, err
+	}
+	tgzr.Reader = tar.NewReader(tgzr.Decompressor)
+	return tgzr, err
 }
-*/
 
-// Close closes the gzip reader
+// Close closes the decompressing reader
 func (tgzr *Reader) Close() error {
-	err := tgzr.GzipReader.Close()
+	err := tgzr.Decompressor.Close()
 	return err
 }
@@ -18,51 +18,85 @@ package targz
 
 import (
 	"archive/tar"
-	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
 )
 
-// Writer encapsulates the tar, gz and file operations of a .tar.gz file.
+// Writer encapsulates the tar, compression and file operations of a compressed tar file (e.g. .tar.gz).
 type Writer struct {
-	*tar.Writer // Tar writer (wraps the GzipWriter)
+	*tar.Writer // Tar writer (wraps the Compressor)
 	//Filename string       // Filename
-	WrappedWriter io.Writer    // File writer
-	GzipWriter    *gzip.Writer // Gzip writer (wraps the WrappedWriter)
+	WrappedWriter io.Writer      // File writer
+	Codec         Codec          // Compression codec
+	Compressor    io.WriteCloser // Compressing writer (wraps the WrappedWriter)
+	file          *os.File       // File opened by NewWriterFromFile
 }
 
-// Close closes all 3 writers.
+// Close closes the tar and compression writers, and the file if it was opened by NewWriterFromFile.
 // Returns the first error
 func (tgzw *Writer) Close() error {
 	err1 := tgzw.Writer.Close()
-	err2 := tgzw.GzipWriter.Close()
+	err2 := tgzw.Compressor.Close()
+	var err3 error
+	if tgzw.file != nil {
+		err3 = tgzw.file.Close()
+	}
 	if err1 != nil {
 		return fmt.Errorf("Error closing Tar Writer %v", err1)
 	}
 	if err2 != nil {
-		return fmt.Errorf("Error closing Gzip Writer %v", err2)
+		return fmt.Errorf("Error closing %s Writer %v", tgzw.Codec.Name(), err2)
+	}
+	if err3 != nil {
+		return fmt.Errorf("Error closing file %v", err3)
 	}
 	return nil
 }
 
-// NewWriterFromFile is a factory for Writer
+// NewWriterFromFile is a factory for Writer.
+// The compression is chosen according to the filename's extension (e.g. '.tar.xz'), using the default level.
 func NewWriterFromFile(archiveFilename string) (*Writer, error) {
+	codec, err := CodecByFilename(archiveFilename)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterFromFileWithCodec(archiveFilename, codec, DefaultCompression)
+}
+
+// NewWriterFromFileWithCodec is a factory for Writer, using the given codec and compression level.
+func NewWriterFromFileWithCodec(archiveFilename string, codec Codec, level int) (*Writer, error) {
 	fw, err := os.Create(archiveFilename)
 	if err != nil {
 		return nil, err
 	}
-	tgzw := NewWriter(fw)
+	tgzw, err := NewWriterWithCodec(fw, codec, level)
+	if err != nil {
+		fw.Close()
+		return nil, err
+	}
+	tgzw.file = fw
 	return tgzw, err
 }
 
 // NewWriter is a factory for Writer.
 // It wraps the io.Writer with a Tar writer and Gzip writer
 func NewWriter(w io.Writer) *Writer {
-	tgzw := &Writer{WrappedWriter: w}
-	// gzip writer
-	tgzw.GzipWriter = gzip.NewWriter(w)
-	// tar writer
-	tgzw.Writer = tar.NewWriter(tgzw.GzipWriter)
+	tgzw, _ := NewWriterWithCodec(w, gzipCodec{}, DefaultCompression)
 	return tgzw
 }
+
+// NewWriterWithCodec is a factory for Writer.
+// It wraps the io.Writer with a Tar writer and the codec's compressing writer
+func NewWriterWithCodec(w io.Writer, codec Codec, level int) (*Writer, error) {
+	tgzw := &Writer{WrappedWriter: w, Codec: codec}
+	var err error
+	// compressing writer
+	tgzw.Compressor, err = codec.NewWriter(w, level)
+	if err != nil {
+		return nil, err
+	}
+	// tar writer
+	tgzw.Writer = tar.NewWriter(tgzw.Compressor)
+	return tgzw, nil
+}